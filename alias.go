@@ -0,0 +1,45 @@
+package rig
+
+// Alias adds names under which the flag can be given on the command line, as in `-addr` for a
+// flag renamed to `-listen`.
+// Noop if the flag has no Name.
+func Alias(f *Flag, aliases ...string) *Flag {
+	if f.Name == "" {
+		return f
+	}
+
+	ret := *f
+	ret.Aliases = append(append([]string{}, f.Aliases...), aliases...)
+	return &ret
+}
+
+// EnvAlias adds environment variables consulted, in order, when Flag.Env isn't set, as in
+// `DATABASE_URL` for a variable renamed to `DB_URL`.
+// Noop if the flag has no Env.
+func EnvAlias(f *Flag, aliases ...string) *Flag {
+	if f.Env == "" {
+		return f
+	}
+
+	ret := *f
+	ret.EnvAliases = append(append([]string{}, f.EnvAliases...), aliases...)
+	return &ret
+}
+
+// names returns the Name of the flag followed by its Aliases, or nil if it has no Name.
+func (f *Flag) names() []string {
+	if f.Name == "" {
+		return nil
+	}
+
+	return append([]string{f.Name}, f.Aliases...)
+}
+
+// envNames returns the Env of the flag followed by its EnvAliases, or nil if it has no Env.
+func (f *Flag) envNames() []string {
+	if f.Env == "" {
+		return nil
+	}
+
+	return append([]string{f.Env}, f.EnvAliases...)
+}
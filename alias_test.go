@@ -0,0 +1,142 @@
+package rig
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAlias(t *testing.T) {
+	var s string
+	f := String(&s, "listen", "LISTEN", "")
+
+	alias := Alias(f, "addr")
+	if !reflect.DeepEqual(alias.Aliases, []string{"addr"}) || f.Aliases != nil {
+		t.Errorf("Alias(...): got %q (original %q), expected %q (original unchanged)", alias.Aliases, f.Aliases, []string{"addr"})
+	}
+	alias = Alias(alias, "address")
+	if !reflect.DeepEqual(alias.Aliases, []string{"addr", "address"}) {
+		t.Errorf("Alias(Alias(...)): got %q, expected %q", alias.Aliases, []string{"addr", "address"})
+	}
+
+	envOnly := String(&s, "", "LISTEN", "")
+	if Alias(envOnly, "addr") != envOnly {
+		t.Errorf("Alias(...): expected a noop for a flag without a Name")
+	}
+}
+
+func TestEnvAlias(t *testing.T) {
+	var s string
+	f := String(&s, "db-url", "DB_URL", "")
+
+	alias := EnvAlias(f, "DATABASE_URL")
+	if !reflect.DeepEqual(alias.EnvAliases, []string{"DATABASE_URL"}) || f.EnvAliases != nil {
+		t.Errorf("EnvAlias(...): got %q (original %q), expected %q (original unchanged)", alias.EnvAliases, f.EnvAliases, []string{"DATABASE_URL"})
+	}
+
+	flagOnly := String(&s, "db-url", "", "")
+	if EnvAlias(flagOnly, "DATABASE_URL") != flagOnly {
+		t.Errorf("EnvAlias(...): expected a noop for a flag without an Env")
+	}
+}
+
+func TestConfigParseAliases(t *testing.T) {
+	var listen, dbURL string
+	newConfig := func(gnu bool) *Config {
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Alias(String(&listen, "listen", "", ""), "addr"),
+				EnvAlias(String(&dbURL, "db-url", "DB_URL", ""), "DATABASE_URL"),
+			},
+			GNU: gnu,
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+		return c
+	}
+
+	for _, test := range []struct {
+		name     string
+		gnu      bool
+		args     []string
+		env      map[string]string
+		listen   string
+		dbURL    string
+		flagName string
+		envName  string
+	}{
+		{name: "name", args: []string{"-listen", ":80"}, listen: ":80", flagName: "listen"},
+		{name: "alias", args: []string{"-addr", ":80"}, listen: ":80", flagName: "addr"},
+		{name: "gnu alias", gnu: true, args: []string{"--addr=:80"}, listen: ":80", flagName: "addr"},
+		{name: "env", env: map[string]string{"DB_URL": "a", "DATABASE_URL": "b"}, dbURL: "a", envName: "DB_URL"},
+		{name: "env alias", env: map[string]string{"DATABASE_URL": "b"}, dbURL: "b", envName: "DATABASE_URL"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			listen, dbURL = "", ""
+			c := newConfig(test.gnu)
+
+			os.Clearenv()
+			for k, v := range test.env {
+				os.Setenv(k, v)
+			}
+			err := c.Parse(test.args)
+			if err != nil {
+				t.Fatalf("Config.Parse(%q): unexpected error: %s", test.args, err)
+			}
+			if listen != test.listen || dbURL != test.dbURL {
+				t.Errorf("Config.Parse(%q): got %q and %q, expected %q and %q", test.args, listen, dbURL, test.listen, test.dbURL)
+			}
+			if test.flagName != "" && c.Flags[0].Origin().Name != test.flagName {
+				t.Errorf("Config.Parse(%q): got origin %q, expected %q", test.args, c.Flags[0].Origin().Name, test.flagName)
+			}
+			if test.envName != "" && c.Flags[1].Origin().Name != test.envName {
+				t.Errorf("Config.Parse(%q): got origin %q, expected %q", test.args, c.Flags[1].Origin().Name, test.envName)
+			}
+		})
+	}
+}
+
+func TestConfigUsageAliases(t *testing.T) {
+	var listen, dbURL string
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags: []*Flag{
+			Short(Alias(String(&listen, "listen", "", ""), "addr"), "l"),
+			EnvAlias(String(&dbURL, "db-url", "DB_URL", ""), "DATABASE_URL"),
+		},
+		GNU: true,
+	}
+	b := &bytes.Buffer{}
+	c.FlagSet.SetOutput(b)
+	c.Usage()
+
+	for _, expected := range []string{
+		"-l, --listen, --addr string",
+		"DB_URL|DATABASE_URL=string",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("Config.Usage(): expected %q to contain %q", b.String(), expected)
+		}
+	}
+}
+
+func TestConfigParseAliasInvalidValue(t *testing.T) {
+	var port int
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags: []*Flag{
+			Short(Alias(Int(&port, "port", "", ""), "p0rt"), "p"),
+		},
+	}
+	c.FlagSet.SetOutput(&bytes.Buffer{})
+
+	os.Clearenv()
+	err := c.Parse([]string{"-p0rt", "foo", "-p", "bar"})
+	expected := `invalid value "foo" for command line flag -p0rt`
+	if err == nil || !strings.HasPrefix(err.Error(), expected) || strings.Contains(err.Error(), "bar") {
+		t.Errorf("Config.Parse(...): expected a single error starting with %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,201 @@
+package rig
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+// A Command is a node in a tree of subcommands. Each command has its own flags, and the first
+// argument left after parsing them selects the subcommand to run.
+type Command struct {
+	// FlagSet is only used by the root command. The subcommands' FlagSets are derived from it,
+	// sharing its ErrorHandling and output. When nil, DefaultFlagSet is used.
+	FlagSet *flag.FlagSet
+
+	// Name is the name of the command on the command line. It is ignored on the root command,
+	// which is named after its FlagSet.
+	Name string
+	// Usage is a one-line description of the command, displayed in its parent's usage.
+	Usage string
+
+	Flags []*Flag
+	// Struct, when not nil, is a pointer to a struct converted to flags using StructToFlags.
+	// These flags are added before Flags, and the struct is validated using ValidateStruct.
+	Struct interface{}
+	// PersistentFlags are available to the command and to all its subcommands. Their sources
+	// and required values are resolved by the command that is run.
+	PersistentFlags []*Flag
+
+	Commands []*Command
+	// Run is called with the remaining arguments once the flags of the command have been
+	// resolved. A command with subcommands and without Run requires a subcommand.
+	Run func(args []string) error
+}
+
+// ParseCommand runs the command tree using os.Args and a flag.FlagSet with its ErrorHandling
+// set to flag.ExitOnError, unless cmd.FlagSet is set.
+func ParseCommand(cmd *Command) error {
+	return cmd.Execute(os.Args[1:])
+}
+
+// Execute parses the arguments provided for the command, then either hands the remaining
+// arguments to the subcommand they name or runs the command. The error returned by Run is
+// returned as is.
+// The argument list provided should not include the command name.
+func (cmd *Command) Execute(arguments []string) error {
+	fs := cmd.FlagSet
+	if fs == nil {
+		fs = DefaultFlagSet()
+	}
+
+	return cmd.execute(fs, nil, arguments)
+}
+
+func (cmd *Command) execute(fs *flag.FlagSet, inherited []*Flag, arguments []string) error {
+	flags := []*Flag{}
+	if cmd.Struct != nil {
+		structFlags, err := StructToFlags(cmd.Struct)
+		if err != nil {
+			return err
+		}
+		flags = append(flags, structFlags...)
+	}
+	flags = append(flags, cmd.Flags...)
+
+	persistent := append(append([]*Flag{}, inherited...), cmd.PersistentFlags...)
+	c := &Config{
+		FlagSet: fs,
+		Flags:   append(flags, persistent...),
+		command: cmd,
+	}
+
+	if len(cmd.Commands) == 0 {
+		err := c.Parse(arguments)
+		if err != nil {
+			return err
+		}
+		err = cmd.validate(c)
+		if err != nil {
+			return err
+		}
+
+		return cmd.run(c.Args())
+	}
+
+	c.FlagSet.Usage = c.Usage
+	c.setDefaultValues()
+
+	errs, err := c.parseFlagset(arguments)
+	if err != nil {
+		return c.handleError(err)
+	}
+
+	args := c.Args()
+	if len(args) > 0 {
+		if sub := cmd.subcommand(args[0]); sub != nil {
+			err = c.resolve(flags, nil, errs) // the persistent flags are resolved by the subcommand
+			if err != nil {
+				return c.handleError(err)
+			}
+			err = cmd.validate(c)
+			if err != nil {
+				return err
+			}
+
+			subFS := flag.NewFlagSet(fs.Name()+" "+sub.Name, fs.ErrorHandling())
+			subFS.SetOutput(fs.Output())
+
+			return sub.execute(subFS, persistent, args[1:])
+		}
+	}
+
+	if cmd.Run == nil {
+		if len(args) == 0 {
+			return c.handleError(errors.New("missing command"))
+		}
+		return c.handleError(fmt.Errorf("unknown command %q%s", args[0], didYouMean(cmd.suggestCommands(args[0]))))
+	}
+
+	err = c.resolve(c.Flags, args, errs)
+	if err != nil {
+		return c.handleError(err)
+	}
+
+	err = c.printConfig()
+	if err != nil {
+		return err
+	}
+	err = cmd.validate(c)
+	if err != nil {
+		return err
+	}
+
+	return cmd.run(args)
+}
+
+// validate validates the command's Struct using ValidateStruct.
+func (cmd *Command) validate(c *Config) error {
+	if cmd.Struct == nil {
+		return nil
+	}
+
+	err := ValidateStruct(cmd.Struct)
+	if err != nil {
+		return c.handleError(err)
+	}
+
+	return nil
+}
+
+func (cmd *Command) run(args []string) error {
+	if cmd.Run == nil {
+		return nil
+	}
+
+	return cmd.Run(args)
+}
+
+func (cmd *Command) subcommand(name string) *Command {
+	for _, sub := range cmd.Commands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+
+	return nil
+}
+
+// suggestCommands returns the subcommands that were likely meant instead of `name`.
+func (cmd *Command) suggestCommands(name string) []string {
+	names := make([]string, 0, len(cmd.Commands))
+	for _, sub := range cmd.Commands {
+		names = append(names, sub.Name)
+	}
+
+	suggestions := suggest(name, names)
+	for i, s := range suggestions {
+		suggestions[i] = strconv.Quote(s)
+	}
+
+	return suggestions
+}
+
+// printCommands prints the tree of subcommands below cmd.
+func (cmd *Command) printCommands(w io.Writer) {
+	lines := [][]string{}
+	var walk func(commands []*Command, indent string)
+	walk = func(commands []*Command, indent string) {
+		for _, sub := range commands {
+			lines = append(lines, []string{indent + sub.Name, sub.Usage})
+			walk(sub.Commands, indent+"  ")
+		}
+	}
+	walk(cmd.Commands, "")
+
+	fmt.Fprint(w, "\nCommands:\n")
+	printUsageLines(w, lines, 2, 4)
+}
@@ -0,0 +1,186 @@
+package rig
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommandExecute(t *testing.T) {
+	type serveConfig struct {
+		Port int    `flag:"port" env:"PORT"`
+		Host string `flag:"host,require"`
+	}
+
+	newTree := func(ran *string, gotArgs *[]string, verbose *bool, serve *serveConfig, dbURL *string) *Command {
+		record := func(name string) func([]string) error {
+			return func(args []string) error {
+				*ran = name
+				*gotArgs = args
+				return nil
+			}
+		}
+
+		return &Command{
+			FlagSet: flag.NewFlagSet("tool", flag.ContinueOnError),
+			PersistentFlags: []*Flag{
+				Bool(verbose, "verbose", "VERBOSE", ""),
+			},
+			Commands: []*Command{
+				{
+					Name:   "serve",
+					Usage:  "start the server",
+					Struct: serve,
+					Run:    record("serve"),
+				},
+				{
+					Name:  "db",
+					Usage: "manage the database",
+					PersistentFlags: []*Flag{
+						Required(String(dbURL, "url", "DB_URL", "")),
+					},
+					Commands: []*Command{
+						{Name: "migrate", Run: record("migrate")},
+					},
+				},
+			},
+		}
+	}
+
+	t.Run("routing", func(t *testing.T) {
+		var (
+			ran     string
+			args    []string
+			verbose bool
+			serve   serveConfig
+			dbURL   string
+		)
+		cmd := newTree(&ran, &args, &verbose, &serve, &dbURL)
+		cmd.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		os.Setenv("PORT", "8080")
+		err := cmd.Execute([]string{"-verbose", "serve", "-host", "localhost", "extra"})
+		if err != nil {
+			t.Fatalf("Command.Execute(...): unexpected error: %s", err)
+		}
+		if ran != "serve" {
+			t.Errorf("Command.Execute(...): ran %q, expected %q", ran, "serve")
+		}
+		if !reflect.DeepEqual(args, []string{"extra"}) {
+			t.Errorf("Command.Execute(...): got args %q, expected %q", args, []string{"extra"})
+		}
+		if !verbose {
+			t.Errorf("Command.Execute(...): expected persistent flag -verbose to be set")
+		}
+		if serve.Port != 8080 || serve.Host != "localhost" {
+			t.Errorf("Command.Execute(...): got %+v, expected port 8080 and host \"localhost\"", serve)
+		}
+	})
+
+	t.Run("nested persistent flags", func(t *testing.T) {
+		var (
+			ran     string
+			args    []string
+			verbose bool
+			serve   serveConfig
+			dbURL   string
+		)
+		cmd := newTree(&ran, &args, &verbose, &serve, &dbURL)
+		cmd.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		os.Setenv("DB_URL", "postgres://")
+		err := cmd.Execute([]string{"db", "migrate", "-verbose"})
+		if err != nil {
+			t.Fatalf("Command.Execute(...): unexpected error: %s", err)
+		}
+		if ran != "migrate" {
+			t.Errorf("Command.Execute(...): ran %q, expected %q", ran, "migrate")
+		}
+		if !verbose || dbURL != "postgres://" {
+			t.Errorf("Command.Execute(...): got verbose=%t url=%q, expected true and %q", verbose, dbURL, "postgres://")
+		}
+	})
+
+	for _, test := range []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "missing command", args: []string{}, expected: "missing command"},
+		{name: "unknown command", args: []string{"nope"}, expected: `unknown command "nope"`},
+		{name: "misspelled command", args: []string{"serv"}, expected: `unknown command "serv" (did you mean "serve"?)`},
+		{name: "missing required persistent flag", args: []string{"db", "migrate"}, expected: "missing command line flag -url or environment variable DB_URL"},
+		{name: "missing required flag", args: []string{"serve"}, expected: "missing command line flag -host or environment variable HOST"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				ran     string
+				args    []string
+				verbose bool
+				serve   serveConfig
+				dbURL   string
+			)
+			cmd := newTree(&ran, &args, &verbose, &serve, &dbURL)
+			cmd.FlagSet.SetOutput(&bytes.Buffer{})
+
+			os.Clearenv()
+			err := cmd.Execute(test.args)
+			if err == nil {
+				t.Fatalf("Command.Execute(%q): expected error, got nil", test.args)
+			}
+			if !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("Command.Execute(%q): expected error %q to contain %q", test.args, err, test.expected)
+			}
+			if ran != "" {
+				t.Errorf("Command.Execute(%q): expected no command to run, ran %q", test.args, ran)
+			}
+		})
+	}
+}
+
+func TestCommandUsage(t *testing.T) {
+	var verbose bool
+	cmd := &Command{
+		FlagSet: flag.NewFlagSet("tool", flag.ContinueOnError),
+		PersistentFlags: []*Flag{
+			Bool(&verbose, "verbose", "", "verbose output"),
+		},
+		Commands: []*Command{
+			{
+				Name:  "db",
+				Usage: "manage the database",
+				Commands: []*Command{
+					{Name: "migrate", Usage: "run the migrations"},
+				},
+			},
+		},
+	}
+	b := &bytes.Buffer{}
+	cmd.FlagSet.SetOutput(b)
+
+	os.Clearenv()
+	_ = cmd.Execute([]string{"-h"})
+
+	for _, expected := range []string{
+		"Usage of tool [options] <command> [arguments]:",
+		"-verbose",
+		"Commands:\n  db           manage the database\n    migrate    run the migrations\n",
+	} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("Command.Execute(-h): expected usage %q to contain %q", b, expected)
+		}
+	}
+
+	b.Reset()
+	cmd.FlagSet = flag.NewFlagSet("tool", flag.ContinueOnError)
+	cmd.FlagSet.SetOutput(b)
+	_ = cmd.Execute([]string{"db", "migrate", "-h"})
+	if !strings.Contains(b.String(), "Usage of tool db migrate [options]:") {
+		t.Errorf("Command.Execute(db migrate -h): unexpected usage %q", b)
+	}
+}
@@ -1,10 +1,10 @@
 package rig
 
 import (
-	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
@@ -32,7 +32,45 @@ func DefaultFlagSet() *flag.FlagSet {
 type Config struct {
 	FlagSet *flag.FlagSet
 
-	Flags            []*Flag
+	Flags []*Flag
+	// Files are consulted, in order, for the flags that were not set from the command line
+	// or the process environment. The first file providing a value for a flag wins.
+	// Files selected using ConfigFile flags take precedence over these. The dotenv files (see
+	// DotEnvFile) are consulted right after the process environment, before any other file.
+	// The files are only loaded when the Sources include FilesSource, or EnvFilesSource for the
+	// dotenv files.
+	Files []*File
+	// Sources are consulted, in order, for the flags that were not set from the command line.
+	// The first source providing a value for a flag wins. When nil, DefaultSources is used.
+	Sources []Source
+	// Groups are sets of mutually exclusive flags, checked once every source has been consulted.
+	// Groups can also be declared with the "group" struct tag (see StructToFlags).
+	Groups []*Group
+	// Rules are constraints between flags, such as RequiredIf, checked after the Groups.
+	Rules []Rule
+	// GNU enables the GNU conventions when parsing the command line: long flags are given as
+	// `--name value` or `--name=value`, and short flags (see Flag.Short) as `-n value` or `-nvalue`.
+	// Boolean short flags can be bundled (`-xvf file`).
+	GNU bool
+	// Interspersed allows the flags to appear after, or between, the positional arguments, as in
+	// `tool input.txt -verbose`. A "--" argument ends the flags: the arguments following it are
+	// positional, even when they look like flags.
+	Interspersed bool
+	// EnvPrefix is the prefix shared by the environment variables of the application, as in
+	// "APP_". When parsing fails, the variables with this prefix that match no flag are reported
+	// as likely typos.
+	EnvPrefix string
+	// StrictEnv makes these variables fail the parse, even when every flag is valid.
+	StrictEnv bool
+	// OnDeprecated is called for each deprecated flag that was set (see Deprecated). When nil,
+	// a warning is written to the FlagSet's output.
+	OnDeprecated func(err *DeprecatedError)
+	// StrictDeprecations makes the deprecated flags that were set fail the parse.
+	StrictDeprecations bool
+
+	files            []*File
+	envFiles         []*File
+	command          *Command
 	defaultValuesSet bool
 }
 
@@ -42,6 +80,10 @@ func (c *Config) setDefaultValues() {
 	}
 
 	for _, f := range c.Flags {
+		f.gnu = c.GNU
+		if f.set { // already parsed by a parent command
+			continue
+		}
 		f.defaultValue = f.Value.String()
 		if f.Name == "" {
 			continue
@@ -50,53 +92,102 @@ func (c *Config) setDefaultValues() {
 	c.defaultValuesSet = true
 }
 
-// Parse parses the arguments provided, along with the environment variables (using os.Getenv).
-// Flags parsed from the `arguments` take precedence over the environment variables.
+// Parse parses the arguments provided, then consults the Sources for the flags that were not set.
+// By default, flags parsed from the `arguments` take precedence over the environment variables
+// (using os.LookupEnv), followed by the dotenv files and the other configuration files.
+// The flags marked with ConfigFile are resolved first, so that the files they select can be loaded.
+// The errors found once the command line is parsed are returned together, as a *ParseError.
 // The argument list provided should not include the command name.
 func (c *Config) Parse(arguments []string) error {
 	c.FlagSet.Usage = c.Usage
 
 	c.setDefaultValues()
 
-	err := c.parseFlagset(arguments)
+	errs, err := c.parseFlagset(arguments)
 	if err != nil {
 		return c.handleError(err)
 	}
 
-	for _, f := range c.Flags {
-		if f.Env == "" || f.set { // environment variables should not overwrite the command-line arguments
-			continue
-		}
-		v, ok := os.LookupEnv(f.Env)
-		if !ok {
-			continue
-		}
+	err = c.resolve(c.Flags, c.FlagSet.Args(), errs)
+	if err != nil {
+		return c.handleError(err)
+	}
 
-		if f.Name != "" { // we want to maintain `"flag".FlagSet.Visit`'s behavior
-			err = c.FlagSet.Set(f.Name, v)
-			if err != nil {
-				return c.handleError(fmt.Errorf("invalid value %q for env variable %q: %w", v, f.Env, err))
-			}
+	return c.printConfig()
+}
+
+// resolve consults the sources for the flags provided that were not set from the command line,
+// then sets the positional flags from `args` and checks the groups, rules and required flags.
+// The errors are collected in `errs`, which is returned unless empty.
+func (c *Config) resolve(flags []*Flag, args []string, errs *ParseError) error {
+	bootstrap := []*Flag{}
+	others := []*Flag{}
+	for _, f := range flags {
+		if f.configFile {
+			bootstrap = append(bootstrap, f)
 			continue
 		}
+		others = append(others, f)
+	}
+	c.applySources(bootstrap, errs)
 
-		err = f.Set(v)
-		if err != nil {
-			return c.handleError(fmt.Errorf("invalid value %q for env variable %q: %w", v, f.Env, err))
-		}
+	err := c.loadFiles(flags)
+	if err != nil {
+		errs.add(err)
+		return errs
+	}
+
+	c.applySources(others, errs)
+	errs.add(parsePositionals(flags, args))
+	errs.add(c.checkDeprecated(flags))
+	errs.add(c.checkGroups(flags))
+	errs.add(c.checkRules())
+	c.addMissingFlags(flags, errs)
+	if c.StrictEnv || len(errs.Errors) > 0 {
+		errs.add(c.checkEnv())
 	}
 
-	err = parsePositionals(c.Flags, c.FlagSet.Args())
+	return errs.errorOrNil()
+}
+
+func (c *Config) set(f *Flag, v string) error {
+	if f.Name == "" {
+		return f.Set(v)
+	}
+
+	// we want to maintain `"flag".FlagSet.Visit`'s behavior
+	err := c.FlagSet.Set(f.Name, v)
 	if err != nil {
-		return c.handleError(err)
+		return err
+	}
+	if arg, ok := c.FlagSet.Lookup(f.Name).Value.(*flagArg); ok {
+		_, err = arg.takeError()
 	}
 
-	err = c.handleMissingFlags()
+	return err
+}
+
+// setList sets a list of values on a repeatable flag, element by element (see Flag.setList).
+func (c *Config) setList(f *Flag, values []string) error {
+	if f.Name == "" {
+		return f.setList(values)
+	}
+
+	arg, ok := c.FlagSet.Lookup(f.Name).Value.(*flagArg)
+	if !ok {
+		return f.setList(values)
+	}
+
+	// the values are set through the FlagSet to maintain `"flag".FlagSet.Visit`'s behavior
+	arg.list = values
+	err := c.FlagSet.Set(f.Name, "")
+	arg.list = nil
 	if err != nil {
-		return c.handleError(err)
+		return err
 	}
+	_, err = arg.takeError()
 
-	return nil
+	return err
 }
 
 func parsePositionals(flags []*Flag, args []string) error {
@@ -112,55 +203,233 @@ func parsePositionals(flags []*Flag, args []string) error {
 		return nil
 	}
 
+	err := validatePositionals(positionalFlags)
+	if err != nil {
+		return err
+	}
+
+	errs := &ParseError{}
+	counts := make([]int, len(positionalFlags))
+	i := 0
 	for _, arg := range args {
-		if len(positionalFlags) == 0 {
-			return errors.New("unexpected positional argument")
+		if i == len(positionalFlags) {
+			errs.add(newUnexpectedPositionalError(positionalFlags[i-1], arg))
+			break
 		}
 
-		err := positionalFlags[0].Set(arg)
+		f := positionalFlags[i]
+		if f.maxArgs > 0 && counts[i] == f.maxArgs {
+			errs.add(newUnexpectedPositionalError(f, arg))
+			break
+		}
+		origin := Origin{
+			Kind: OriginPositional,
+			Name: f.Name,
+			Raw:  arg,
+		}
+		err := f.setFrom(arg, origin)
 		if err != nil {
-			return err
+			errs.add(newInvalidValueError(f, origin, err))
+		}
+		counts[i]++
+
+		if !isSliceFlag(f) {
+			i++
+		}
+	}
+
+	for i, f := range positionalFlags {
+		if counts[i] < f.minArgs && (counts[i] > 0 || !f.set) && !errs.failed(f) {
+			errs.add(&MissingRequiredError{Flag: f, Got: counts[i]})
+		}
+	}
+
+	return errs.errorOrNil()
+}
+
+// validatePositionals rejects the layouts of positional flags that cannot be parsed
+// unambiguously: a repeatable positional flag must come last, and a required one cannot follow
+// an optional one.
+func validatePositionals(positionalFlags []*Flag) error {
+	var optional *Flag
+	for i, f := range positionalFlags {
+		name := positionalName(f)
+		slice := isSliceFlag(f)
+		switch {
+		case (f.minArgs != 0 || f.maxArgs != 0) && !slice:
+			return fmt.Errorf("positional argument %s: arity is only supported by repeatable flags", name)
+		case f.minArgs < 0 || f.maxArgs < 0 || (f.maxArgs > 0 && f.minArgs > f.maxArgs):
+			return fmt.Errorf("positional argument %s: invalid arity (min %d, max %d)", name, f.minArgs, f.maxArgs)
+		case slice && i < len(positionalFlags)-1:
+			return fmt.Errorf("repeatable positional argument %s must be the last positional argument", name)
 		}
 
-		if _, ok := positionalFlags[0].Value.(sliceValue); len(positionalFlags) > 1 || !ok {
-			positionalFlags = positionalFlags[1:]
+		required := f.Required || f.minArgs > 0
+		if required && optional != nil {
+			return fmt.Errorf("required positional argument %s cannot follow optional positional argument %s", name, positionalName(optional))
+		}
+		if !required {
+			optional = f
 		}
 	}
 
 	return nil
 }
 
-func (c *Config) parseFlagset(arguments []string) error {
+// positionalName is the name of a positional flag in the usage and errors.
+func positionalName(f *Flag) string {
+	if f.Env != "" {
+		return f.Env
+	}
+
+	return f.Name
+}
+
+// undefinedFlagPrefix starts the errors returned by flag.FlagSet.Parse for undefined flags.
+const undefinedFlagPrefix = "flag provided but not defined: "
+
+// parseFlagset parses the command line. The invalid values are returned in a ParseError, to be
+// reported along with the errors found by resolve, while the other errors are returned as err.
+func (c *Config) parseFlagset(arguments []string) (errs *ParseError, err error) {
+	args := []*flagArg{}
 	for _, f := range c.Flags {
-		if f.Name == "" {
+		if f.Name == "" || f.rest {
 			continue
 		}
-		c.FlagSet.Var(f, f.Name, f.Usage)
+		names := f.names()
+		if f.Short != "" {
+			names = append(names, f.Short)
+		}
+		for _, name := range names {
+			arg := &flagArg{Flag: f, name: name}
+			args = append(args, arg)
+			c.FlagSet.Var(arg, name, f.Usage)
+		}
 	}
 
-	err := c.FlagSet.Parse(arguments)
+	switch {
+	case c.GNU:
+		err = c.parseGNU(arguments)
+	case c.Interspersed || c.restFlag() != nil:
+		err = c.parseQuietly(c.moveFlagsFirst(arguments))
+	default:
+		err = c.parseQuietly(arguments)
+	}
 	if err != nil {
-		return err
+		if arg := strings.TrimPrefix(err.Error(), undefinedFlagPrefix); arg != err.Error() {
+			return nil, c.unknownFlagError(arg, false)
+		}
+		return nil, err
 	}
 
-	return nil
+	errs = &ParseError{}
+	for _, arg := range args {
+		raw, err := arg.takeError()
+		if err != nil && !errs.failed(arg.Flag) { // the flag might have been given under several names
+			errs.add(newInvalidValueError(arg.Flag, Origin{Kind: OriginFlag, Name: arg.name, Raw: raw, gnu: c.GNU}, err))
+		}
+	}
+
+	return errs, nil
 }
 
-func (c *Config) handleMissingFlags() error {
-	hasMissing := false
-	for _, f := range c.Flags {
-		if !f.Required || f.set {
+// parseQuietly parses the arguments with the FlagSet, without letting it print its errors or
+// exit: they are reported once by Config, using handleError, along with the suggestions.
+func (c *Config) parseQuietly(arguments []string) error {
+	fs := c.FlagSet
+	name, errorHandling, output, usage := fs.Name(), fs.ErrorHandling(), fs.Output(), fs.Usage
+	fs.Init(name, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Usage = func() {}
+	defer func() {
+		fs.Init(name, errorHandling)
+		fs.SetOutput(output)
+		fs.Usage = usage
+	}()
+
+	return fs.Parse(arguments)
+}
+
+// moveFlagsFirst reorders the arguments so that the flags, and their values, come before the
+// other arguments, which are placed after a "--" terminator. Unless Config.Interspersed is set,
+// the first positional argument ends the flags. The arguments following "--" are collected by
+// the Rest flag, if any.
+func (c *Config) moveFlagsFirst(arguments []string) []string {
+	flags := []string{}
+	args := []string{}
+	for i := 0; i < len(arguments); i++ {
+		arg := arguments[i]
+		if arg == "--" {
+			if c.restFlag() != nil {
+				c.setRest(arguments[i+1:])
+			} else {
+				args = append(args, arguments[i+1:]...)
+			}
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			if c.Interspersed {
+				args = append(args, arg)
+				continue
+			}
+			before, rest, ok := c.splitRest(arguments[i:])
+			if ok {
+				c.setRest(rest)
+			}
+			args = append(args, before...)
+			break
+		}
+
+		flags = append(flags, arg)
+		name := strings.TrimPrefix(arg[1:], "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		f := c.FlagSet.Lookup(name)
+		if f == nil { // reported by the FlagSet
+			continue
+		}
+		if boolFlagger, ok := f.Value.(isBoolFlagger); ok && boolFlagger.IsBoolFlag() {
+			continue
+		}
+		if i+1 == len(arguments) { // reported by the FlagSet
+			return flags
+		}
+		i++
+		flags = append(flags, arguments[i])
+	}
+
+	return append(append(flags, "--"), args...)
+}
+
+// addMissingFlags adds an error for each required flag that hasn't been set, unless a value
+// was rejected for it.
+func (c *Config) addMissingFlags(flags []*Flag, errs *ParseError) {
+	for _, f := range flags {
+		if !f.Required || f.set || errs.failed(f) {
 			continue
 		}
 
-		fmt.Fprintln(c.FlagSet.Output(), f.missingError())
-		hasMissing = true
+		errs.add(&MissingRequiredError{Flag: f})
 	}
-	if hasMissing {
-		return errors.New("missing required values")
+}
+
+// Visit calls fn for each flag that has been set, in the order of Config.Flags.
+// Flag.Origin reports where the value of each flag came from.
+func (c *Config) Visit(fn func(*Flag)) {
+	for _, f := range c.Flags {
+		if f.set {
+			fn(f)
+		}
 	}
+}
 
-	return nil
+// VisitAll calls fn for each flag, including the ones left to their default value, in the order
+// of Config.Flags.
+func (c *Config) VisitAll(fn func(*Flag)) {
+	for _, f := range c.Flags {
+		fn(f)
+	}
 }
 
 // Arg proxies the .Arg method on the underlying flag.Flagset
@@ -174,6 +443,17 @@ func (c *Config) Args() []string {
 }
 
 func (c *Config) handleError(err error) error {
+	if err == flag.ErrHelp { // as reported by flag.FlagSet.Parse
+		c.Usage()
+		switch c.FlagSet.ErrorHandling() {
+		case flag.ExitOnError:
+			os.Exit(0)
+		case flag.PanicOnError:
+			panic(err)
+		}
+		return err
+	}
+
 	fmt.Fprintf(c.FlagSet.Output(), "%s\n", err)
 	c.Usage()
 	switch c.FlagSet.ErrorHandling() {
@@ -248,26 +528,44 @@ func (c *Config) Usage() {
 	if hasNonPos {
 		fmt.Fprint(b, " [options]")
 	}
+	for _, g := range c.groups(c.Flags) {
+		fmt.Fprintf(b, " %s", g.usage())
+	}
 	for _, pos := range positionals {
-		name := pos.Env
-		if name == "" {
-			name = pos.Name
-		}
-		if _, ok := pos.Value.(sliceValue); ok {
+		name := positionalName(pos)
+		if isSliceFlag(pos) {
 			name += "..."
 		}
-		if pos.Required {
+		if pos.Required || pos.minArgs > 0 {
 			fmt.Fprintf(b, " %s", name)
 		} else {
 			fmt.Fprintf(b, " [%s]", name)
 		}
 	}
+	if f := c.restFlag(); f != nil {
+		if f.Required {
+			fmt.Fprint(b, " -- args...")
+		} else {
+			fmt.Fprint(b, " [-- args...]")
+		}
+	}
+	if c.command != nil && len(c.command.Commands) > 0 {
+		if c.command.Run == nil {
+			fmt.Fprint(b, " <command> [arguments]")
+		} else {
+			fmt.Fprint(b, " [command] [arguments]")
+		}
+	}
 
 	fmt.Fprint(b, ":\n")
 	fmt.Fprint(c.FlagSet.Output(), b.String())
 	// fmt.Fprintf(c.FlagSet.Output(), "Usage of %s:\n", c.FlagSet.Name())
 
 	printUsageLines(c.FlagSet.Output(), lines, 2, 4)
+
+	if c.command != nil && len(c.command.Commands) > 0 {
+		c.command.printCommands(c.FlagSet.Output())
+	}
 }
 
 func printUsageLines(output io.Writer, lines [][]string, margin, sep int) {
@@ -333,11 +631,11 @@ func (c *Config) flagUsage(f *Flag) []string {
 	line := []string{}
 	switch {
 	case f.Name != "" && f.Env != "":
-		line = append(line, flagUsageExample(f, typ), f.Env+"="+formatTypeHint(typ))
+		line = append(line, c.flagUsageExample(f, typ), strings.Join(f.envNames(), "|")+"="+formatTypeHint(typ))
 	case f.Name != "":
-		line = append(line, flagUsageExample(f, typ), "")
+		line = append(line, c.flagUsageExample(f, typ), "")
 	case f.Env != "":
-		line = append(line, "", f.Env+"="+formatTypeHint(typ))
+		line = append(line, "", strings.Join(f.envNames(), "|")+"="+formatTypeHint(typ))
 	}
 
 	usage := c.flagUsageDoc(f)
@@ -348,6 +646,24 @@ func (c *Config) flagUsage(f *Flag) []string {
 	return line
 }
 
+// deprecatedAliasesUsage describes the deprecated aliases of the flag, as in
+// "deprecated alias -addr: use -listen instead".
+func (c *Config) deprecatedAliasesUsage(f *Flag) []string {
+	notes := []string{}
+	for i, message := range f.deprecatedAliases {
+		if message != "" {
+			notes = append(notes, "deprecated alias "+c.flagSpelling(f.Aliases[i])+": "+message)
+		}
+	}
+	for i, message := range f.deprecatedEnvAliases {
+		if message != "" {
+			notes = append(notes, "deprecated alias "+f.EnvAliases[i]+": "+message)
+		}
+	}
+
+	return notes
+}
+
 func formatTypeHint(typ string) string {
 	if strings.IndexFunc(typ, unicode.IsSpace) == -1 {
 		return typ
@@ -356,27 +672,40 @@ func formatTypeHint(typ string) string {
 	return strconv.Quote(typ)
 }
 
-func flagUsageExample(f *Flag, typ string) string {
+func (c *Config) flagUsageExample(f *Flag, typ string) string {
+	names := []string{}
+	if f.Short != "" {
+		names = append(names, "-"+f.Short)
+	}
+	for _, name := range f.names() {
+		names = append(names, c.flagSpelling(name))
+	}
+	name := strings.Join(names, ", ")
 	if f.IsBoolFlag() {
-		return fmt.Sprintf("-%s", f.Name)
+		return name
 	}
 
-	return fmt.Sprintf("-%s %s", f.Name, formatTypeHint(typ))
+	return fmt.Sprintf("%s %s", name, formatTypeHint(typ))
 }
 
 func (c *Config) flagUsageDoc(f *Flag) string {
 	s := ""
 
+	defaultValue := f.defaultValue
+	if f.Secret {
+		defaultValue = maskSecret(defaultValue)
+	}
+
 	switch {
 	case f.Usage != "":
 		s += f.Usage
-		if f.defaultValue != "" && !f.Required {
-			s += fmt.Sprintf(" (default %q)", f.defaultValue)
+		if defaultValue != "" && !f.Required {
+			s += fmt.Sprintf(" (default %q)", defaultValue)
 		} else if f.Required {
 			s += " (required)"
 		}
-	case f.defaultValue != "" && !f.Required:
-		s += fmt.Sprintf("(default %q)", f.defaultValue)
+	case defaultValue != "" && !f.Required:
+		s += fmt.Sprintf("(default %q)", defaultValue)
 	case f.Required:
 		s += "(required)"
 	}
@@ -389,6 +718,30 @@ func (c *Config) flagUsageDoc(f *Flag) string {
 			s = "(positional)"
 		}
 	}
+	if f.configFile {
+		switch s {
+		default:
+			s += " (configuration file)"
+		case "":
+			s = "(configuration file)"
+		}
+	}
+	if f.Deprecated != "" {
+		switch s {
+		default:
+			s += " (deprecated: " + f.Deprecated + ")"
+		case "":
+			s = "(deprecated: " + f.Deprecated + ")"
+		}
+	}
+	for _, note := range c.deprecatedAliasesUsage(f) {
+		switch s {
+		default:
+			s += " (" + note + ")"
+		case "":
+			s = "(" + note + ")"
+		}
+	}
 
 	return s
 }
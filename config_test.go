@@ -451,6 +451,270 @@ func TestConfigArgArgs(t *testing.T) {
 	}
 }
 
+func TestConfigParseInterspersed(t *testing.T) {
+	for _, gnu := range []bool{false, true} {
+		for _, test := range []struct {
+			args          []string
+			expectedName  string
+			expectedFiles []string
+			expectedArgs  []string
+			verbose       bool
+		}{
+			{
+				args:          []string{"input.txt", "--verbose", "other.txt"},
+				expectedFiles: []string{"input.txt", "other.txt"},
+				expectedArgs:  []string{"input.txt", "other.txt"},
+				verbose:       true,
+			},
+			{
+				args:          []string{"input.txt", "--name", "foo", "-", "--", "--verbose"},
+				expectedName:  "foo",
+				expectedFiles: []string{"input.txt", "-", "--verbose"},
+				expectedArgs:  []string{"input.txt", "-", "--verbose"},
+			},
+			{
+				args:          []string{"--name=-x", "input.txt", "--verbose=false"},
+				expectedName:  "-x",
+				expectedFiles: []string{"input.txt"},
+				expectedArgs:  []string{"input.txt"},
+			},
+		} {
+			var (
+				name    string
+				verbose bool
+				files   []string
+			)
+			c := &Config{
+				FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+				Flags: []*Flag{
+					String(&name, "name", "", ""),
+					Bool(&verbose, "verbose", "", ""),
+					Positional(Repeatable(&files, StringGenerator(), "files", "", "")),
+				},
+				GNU:          gnu,
+				Interspersed: true,
+			}
+			c.FlagSet.SetOutput(&bytes.Buffer{})
+
+			os.Clearenv()
+			err := c.Parse(test.args)
+			if err != nil {
+				t.Errorf("Config.Parse(%q) (GNU: %t): unexpected error: %s", test.args, gnu, err)
+				continue
+			}
+			if name != test.expectedName || verbose != test.verbose {
+				t.Errorf("Config.Parse(%q) (GNU: %t): got name=%q verbose=%t, expected %q and %t", test.args, gnu, name, verbose, test.expectedName, test.verbose)
+			}
+			if !reflect.DeepEqual(files, test.expectedFiles) {
+				t.Errorf("Config.Parse(%q) (GNU: %t): got files %q, expected %q", test.args, gnu, files, test.expectedFiles)
+			}
+			if !reflect.DeepEqual(c.Args(), test.expectedArgs) {
+				t.Errorf("Config.Args() (GNU: %t) = %q, expected %q", gnu, c.Args(), test.expectedArgs)
+			}
+		}
+	}
+}
+
+func TestParsePositionalsArity(t *testing.T) {
+	for _, test := range []struct {
+		args          []string
+		expectedFiles []string
+		expectedError string
+	}{
+		{args: []string{"out", "a"}, expectedFiles: []string{"a"}},
+		{args: []string{"out", "a", "b", "c"}, expectedFiles: []string{"a", "b", "c"}},
+		{args: []string{"out"}, expectedError: "missing values for positional argument files: expected at least 1, got 0"},
+		{args: []string{"out", "a", "b", "c", "d"}, expectedError: "too many values for positional argument files: expected at most 3"},
+	} {
+		var (
+			out   string
+			files []string
+		)
+		flags := []*Flag{
+			Positional(Required(String(&out, "out", "", ""))),
+			Arity(Positional(Repeatable(&files, StringGenerator(), "files", "", "")), 1, 3),
+		}
+
+		err := parsePositionals(flags, test.args)
+		if test.expectedError != "" {
+			if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+				t.Errorf("parsePositionals(%q): expected error %q, got %v", test.args, test.expectedError, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsePositionals(%q): unexpected error: %s", test.args, err)
+			continue
+		}
+		if !reflect.DeepEqual(files, test.expectedFiles) {
+			t.Errorf("parsePositionals(%q): got files %q, expected %q", test.args, files, test.expectedFiles)
+		}
+	}
+
+	var a, b string
+	flags := []*Flag{
+		Positional(String(&a, "a", "", "")),
+		Positional(String(&b, "b", "", "")),
+	}
+	err := parsePositionals(flags, []string{"1", "2", "3"})
+	if err == nil || !strings.Contains(err.Error(), `unexpected positional argument "3" after b`) {
+		t.Errorf("parsePositionals(...): expected the extra argument to be named, got %v", err)
+	}
+}
+
+func TestConfigParsePositionalArity(t *testing.T) {
+	type config struct {
+		Files []string `flag:"files,positional,require,min=1" env:"-"`
+	}
+	for _, required := range []bool{true, false} {
+		var v config
+		flags, err := StructToFlags(&v)
+		if err != nil {
+			t.Fatalf("StructToFlags(...): unexpected error: %s", err)
+		}
+		flags[0].Required = required
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags:   flags,
+		}
+		b := &bytes.Buffer{}
+		c.FlagSet.SetOutput(b)
+
+		os.Clearenv()
+		err = c.Parse([]string{})
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) || len(parseErr.Errors) != 1 {
+			t.Fatalf("Config.Parse(...) (required=%t): expected a single error, got %v", required, err)
+		}
+		var missing *MissingRequiredError
+		if !errors.As(err, &missing) || missing.Flag != flags[0] || missing.Got != 0 {
+			t.Errorf("Config.Parse(...) (required=%t): expected a *MissingRequiredError for files, got %+v", required, err)
+		}
+		if !strings.Contains(b.String(), "Usage of flagset files...:\n") {
+			t.Errorf("Config.Parse(...) (required=%t): expected the usage to show files as required, got %q", required, b.String())
+		}
+	}
+}
+
+func TestValidatePositionals(t *testing.T) {
+	var (
+		s  string
+		i  int
+		ss []string
+	)
+	for _, test := range []struct {
+		flags         []*Flag
+		expectedError string
+	}{
+		{
+			flags: []*Flag{
+				Positional(Required(String(&s, "s", "", ""))),
+				Positional(Int(&i, "i", "", "")),
+				Arity(Positional(Repeatable(&ss, StringGenerator(), "ss", "", "")), 0, 2),
+			},
+		},
+		{
+			flags: []*Flag{
+				Positional(String(&s, "s", "", "")),
+				Positional(Required(Int(&i, "i", "", ""))),
+			},
+			expectedError: "required positional argument i cannot follow optional positional argument s",
+		},
+		{
+			flags: []*Flag{
+				Positional(String(&s, "s", "", "")),
+				Arity(Positional(Repeatable(&ss, StringGenerator(), "ss", "", "")), 1, 0),
+			},
+			expectedError: "required positional argument ss cannot follow optional positional argument s",
+		},
+		{
+			flags: []*Flag{
+				Positional(Repeatable(&ss, StringGenerator(), "ss", "", "")),
+				Positional(String(&s, "s", "", "")),
+			},
+			expectedError: "repeatable positional argument ss must be the last positional argument",
+		},
+		{
+			flags: []*Flag{
+				Arity(Positional(String(&s, "s", "", "")), 1, 2),
+			},
+			expectedError: "positional argument s: arity is only supported by repeatable flags",
+		},
+		{
+			flags: []*Flag{
+				Arity(Positional(Repeatable(&ss, StringGenerator(), "ss", "", "")), 3, 2),
+			},
+			expectedError: "positional argument ss: invalid arity",
+		},
+	} {
+		err := validatePositionals(test.flags)
+		if test.expectedError == "" {
+			if err != nil {
+				t.Errorf("validatePositionals(...): unexpected error: %s", err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), test.expectedError) {
+			t.Errorf("validatePositionals(...): expected error %q, got %v", test.expectedError, err)
+		}
+	}
+}
+
+func TestConfigVisit(t *testing.T) {
+	path := writeTestFile(t, "config.yaml", "from-file: 1\n")
+
+	var (
+		fromArgs, fromEnv, fromFile, fromDefault, positional int
+	)
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags: []*Flag{
+			Int(&fromArgs, "from-args", "FROM_ARGS", ""),
+			Int(&fromEnv, "from-env", "FROM_ENV", ""),
+			Int(&fromFile, "from-file", "FROM_FILE", ""),
+			Int(&fromDefault, "from-default", "FROM_DEFAULT", ""),
+			Positional(Int(&positional, "positional", "", "")),
+		},
+		Files: []*File{YAMLFile(path)},
+	}
+	c.FlagSet.SetOutput(&bytes.Buffer{})
+
+	os.Clearenv()
+	os.Setenv("FROM_ENV", "2")
+	err := c.Parse([]string{"-from-args=3", "4"})
+	if err != nil {
+		t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+	}
+
+	expected := map[string]Origin{
+		"from-args":  {Kind: OriginFlag, Name: "from-args", Raw: "3"},
+		"from-env":   {Kind: OriginEnv, Name: "FROM_ENV", Raw: "2"},
+		"from-file":  {Kind: OriginFile, Name: "from-file", File: path, Line: 1, Raw: "1"},
+		"positional": {Kind: OriginPositional, Name: "positional", Raw: "4"},
+	}
+	visited := 0
+	c.Visit(func(f *Flag) {
+		visited++
+		if f.Origin() != expected[f.Name] {
+			t.Errorf("-%s: Origin() = %+v, expected %+v", f.Name, f.Origin(), expected[f.Name])
+		}
+	})
+	if visited != len(expected) {
+		t.Errorf("Config.Visit: visited %d flags, expected %d", visited, len(expected))
+	}
+
+	visited = 0
+	c.VisitAll(func(f *Flag) {
+		visited++
+		if f.Name == "from-default" && f.Origin() != (Origin{Kind: OriginDefault, Raw: "0"}) {
+			t.Errorf("-%s: Origin() = %+v, expected the default value", f.Name, f.Origin())
+		}
+	})
+	if visited != len(c.Flags) {
+		t.Errorf("Config.VisitAll: visited %d flags, expected %d", visited, len(c.Flags))
+	}
+}
+
 const testHandleErrorExitOnErrorEnv = "TEST_HANDLE_ERROR_CRASHER"
 
 var errTest = errors.New("test error")
@@ -0,0 +1,121 @@
+package rig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Deprecated marks a flag as deprecated. Config.Parse warns when the flag is set by any source,
+// and Config.Usage shows the message, which should explain how to migrate, as in
+// "use -listen instead".
+func Deprecated(f *Flag, message string) *Flag {
+	ret := *f
+	ret.Deprecated = message
+	return &ret
+}
+
+// DeprecatedAlias marks one of the Aliases or EnvAliases of the flag as deprecated. Config.Parse
+// only warns when the flag is set through that alias, and Config.Usage shows the message.
+// Noop if alias isn't one of the flag's Aliases or EnvAliases.
+func DeprecatedAlias(f *Flag, alias, message string) *Flag {
+	ret := *f
+	ret.deprecatedAliases = markDeprecated(f.deprecatedAliases, f.Aliases, alias, message)
+	ret.deprecatedEnvAliases = markDeprecated(f.deprecatedEnvAliases, f.EnvAliases, alias, message)
+	return &ret
+}
+
+// markDeprecated returns a copy of messages, the migration hints of names by index, with the
+// message of alias set.
+func markDeprecated(messages, names []string, alias, message string) []string {
+	for i, name := range names {
+		if name != alias {
+			continue
+		}
+
+		ret := make([]string, len(names))
+		copy(ret, messages)
+		ret[i] = message
+		return ret
+	}
+
+	return messages
+}
+
+// deprecatedAlias returns the deprecated alias the value described by origin was given
+// through, along with its migration hint.
+func (f *Flag) deprecatedAlias(origin Origin) (alias, message string) {
+	names, messages := f.Aliases, f.deprecatedAliases
+	switch origin.Kind {
+	case OriginFlag, OriginFile:
+	case OriginEnv, OriginEnvFile:
+		names, messages = f.EnvAliases, f.deprecatedEnvAliases
+	default:
+		return "", ""
+	}
+
+	name := origin.Name
+	switch origin.Kind {
+	case OriginFile: // the nested keys are matched by their names joined with "-" (see File.Lookup)
+		name = strings.ReplaceAll(name, ".", "-")
+	case OriginEnvFile:
+		name = strings.TrimSuffix(name, "_FILE")
+	}
+	for i, alias := range names {
+		if alias == name && i < len(messages) && messages[i] != "" {
+			return alias, messages[i]
+		}
+	}
+
+	return "", ""
+}
+
+// A DeprecatedError is reported for each deprecated flag set by any source, or set through a
+// deprecated alias: as a warning, or as an error when Config.StrictDeprecations is set.
+type DeprecatedError struct {
+	Flag *Flag
+	// Origin describes where the value came from.
+	Origin Origin
+	// Alias is the deprecated alias the flag was set through. It is empty when the flag itself
+	// is deprecated.
+	Alias string
+}
+
+func (e *DeprecatedError) Error() string {
+	if e.Alias != "" {
+		_, message := e.Flag.deprecatedAlias(e.Origin)
+		return fmt.Sprintf("%s (alias of %s) is deprecated: %s", e.Origin, flagDisplayName(e.Flag), message)
+	}
+
+	return fmt.Sprintf("%s (%s) is deprecated: %s", flagDisplayName(e.Flag), e.Origin, e.Flag.Deprecated)
+}
+
+// checkDeprecated reports the deprecated flags and aliases that were set, returning them as
+// errors when Config.StrictDeprecations is set.
+func (c *Config) checkDeprecated(flags []*Flag) error {
+	errs := &ParseError{}
+	for _, f := range flags {
+		if !f.set {
+			continue
+		}
+
+		err := &DeprecatedError{Flag: f, Origin: f.Origin()}
+		if f.Deprecated == "" {
+			alias, message := f.deprecatedAlias(err.Origin)
+			if message == "" {
+				continue
+			}
+			err.Alias = alias
+		}
+
+		switch {
+		case c.StrictDeprecations:
+			errs.add(err)
+		case c.OnDeprecated != nil:
+			c.OnDeprecated(err)
+		default:
+			fmt.Fprintf(c.FlagSet.Output(), "warning: %s\n", err)
+		}
+	}
+
+	return errs.errorOrNil()
+}
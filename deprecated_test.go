@@ -0,0 +1,242 @@
+package rig
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDeprecated(t *testing.T) {
+	var s string
+	f := String(&s, "addr", "", "")
+
+	deprecated := Deprecated(f, "use -listen instead")
+	if deprecated.Deprecated != "use -listen instead" || f.Deprecated != "" {
+		t.Errorf("Deprecated(...): got %q (original %q), expected %q (original unchanged)", deprecated.Deprecated, f.Deprecated, "use -listen instead")
+	}
+}
+
+func TestConfigParseDeprecated(t *testing.T) {
+	var listen, addr string
+	newConfig := func() (*Config, *bytes.Buffer) {
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				String(&listen, "listen", "LISTEN", ""),
+				Deprecated(String(&addr, "addr", "ADDR", ""), "use -listen instead"),
+			},
+		}
+		b := &bytes.Buffer{}
+		c.FlagSet.SetOutput(b)
+		return c, b
+	}
+
+	t.Run("warning", func(t *testing.T) {
+		c, b := newConfig()
+
+		os.Clearenv()
+		os.Setenv("ADDR", ":80")
+		err := c.Parse([]string{})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+		expected := `warning: -addr (env variable "ADDR") is deprecated: use -listen instead` + "\n"
+		if b.String() != expected {
+			t.Errorf("Config.Parse(...): got output %q, expected %q", b.String(), expected)
+		}
+	})
+
+	t.Run("not set", func(t *testing.T) {
+		c, b := newConfig()
+
+		os.Clearenv()
+		err := c.Parse([]string{"-listen", ":80"})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+		if b.Len() != 0 {
+			t.Errorf("Config.Parse(...): expected no output, got %q", b.String())
+		}
+	})
+
+	t.Run("hook", func(t *testing.T) {
+		c, b := newConfig()
+		deprecations := []*DeprecatedError{}
+		c.OnDeprecated = func(err *DeprecatedError) {
+			deprecations = append(deprecations, err)
+		}
+
+		os.Clearenv()
+		err := c.Parse([]string{"-addr", ":80"})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+		if len(deprecations) != 1 || deprecations[0].Flag != c.Flags[1] || deprecations[0].Origin.Kind != OriginFlag {
+			t.Errorf("Config.Parse(...): expected OnDeprecated to be called once for -addr, got %+v", deprecations)
+		}
+		if b.Len() != 0 {
+			t.Errorf("Config.Parse(...): expected no output, got %q", b.String())
+		}
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		c, _ := newConfig()
+		c.StrictDeprecations = true
+
+		os.Clearenv()
+		err := c.Parse([]string{"-addr", ":80"})
+		var deprecated *DeprecatedError
+		if !errors.As(err, &deprecated) || deprecated.Flag != c.Flags[1] {
+			t.Errorf("Config.Parse(...): expected a *DeprecatedError for -addr, got %v", err)
+		}
+	})
+}
+
+func TestConfigUsageDeprecated(t *testing.T) {
+	var addr string
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags: []*Flag{
+			Deprecated(String(&addr, "addr", "", "address to listen on"), "use -listen instead"),
+		},
+	}
+	b := &bytes.Buffer{}
+	c.FlagSet.SetOutput(b)
+	c.Usage()
+
+	expected := "address to listen on (deprecated: use -listen instead)"
+	if !strings.Contains(b.String(), expected) {
+		t.Errorf("Config.Usage(): expected %q to contain %q", b.String(), expected)
+	}
+}
+
+func TestDeprecatedAlias(t *testing.T) {
+	var s string
+	f := EnvAlias(Alias(String(&s, "listen", "LISTEN", ""), "addr"), "ADDR")
+
+	deprecated := DeprecatedAlias(DeprecatedAlias(f, "addr", "use -listen instead"), "ADDR", "use LISTEN instead")
+	if !reflect.DeepEqual(deprecated.deprecatedAliases, []string{"use -listen instead"}) || !reflect.DeepEqual(deprecated.deprecatedEnvAliases, []string{"use LISTEN instead"}) {
+		t.Errorf("DeprecatedAlias(...): got %q and %q, expected %q and %q", deprecated.deprecatedAliases, deprecated.deprecatedEnvAliases, []string{"use -listen instead"}, []string{"use LISTEN instead"})
+	}
+	if f.deprecatedAliases != nil || f.deprecatedEnvAliases != nil || deprecated.Deprecated != "" {
+		t.Errorf("DeprecatedAlias(...): expected the original flag to be unchanged and the flag not to be deprecated")
+	}
+
+	unknown := DeprecatedAlias(f, "address", "use -listen instead")
+	if unknown.deprecatedAliases != nil || unknown.deprecatedEnvAliases != nil {
+		t.Errorf("DeprecatedAlias(...): expected an unknown alias to be ignored, got %q and %q", unknown.deprecatedAliases, unknown.deprecatedEnvAliases)
+	}
+}
+
+func TestConfigParseDeprecatedAlias(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		gnu      bool
+		args     []string
+		env      map[string]string
+		expected string
+	}{
+		{name: "name", args: []string{"-listen", ":80"}},
+		{name: "alias", args: []string{"-addr", ":80"}, expected: "warning: command line flag -addr (alias of -listen) is deprecated: use -listen instead\n"},
+		{name: "gnu alias", gnu: true, args: []string{"--addr=:80"}, expected: "warning: command line flag --addr (alias of --listen) is deprecated: use -listen instead\n"},
+		{name: "env", env: map[string]string{"LISTEN": ":80"}},
+		{name: "env alias", env: map[string]string{"ADDR": ":80"}, expected: `warning: env variable "ADDR" (alias of -listen) is deprecated: use LISTEN instead` + "\n"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var listen string
+			c := &Config{
+				FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+				Flags: []*Flag{
+					DeprecatedAlias(DeprecatedAlias(EnvAlias(Alias(String(&listen, "listen", "LISTEN", ""), "addr"), "ADDR"), "addr", "use -listen instead"), "ADDR", "use LISTEN instead"),
+				},
+				GNU: test.gnu,
+			}
+			b := &bytes.Buffer{}
+			c.FlagSet.SetOutput(b)
+
+			os.Clearenv()
+			for k, v := range test.env {
+				os.Setenv(k, v)
+			}
+			err := c.Parse(test.args)
+			if err != nil {
+				t.Fatalf("Config.Parse(%q): unexpected error: %s", test.args, err)
+			}
+			if b.String() != test.expected {
+				t.Errorf("Config.Parse(%q): got output %q, expected %q", test.args, b.String(), test.expected)
+			}
+		})
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		var listen string
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				DeprecatedAlias(Alias(String(&listen, "listen", "", ""), "addr"), "addr", "use -listen instead"),
+			},
+			StrictDeprecations: true,
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		err := c.Parse([]string{"-addr", ":80"})
+		var deprecated *DeprecatedError
+		if !errors.As(err, &deprecated) || deprecated.Alias != "addr" {
+			t.Errorf("Config.Parse(...): expected a *DeprecatedError for the alias -addr, got %v", err)
+		}
+	})
+}
+
+func TestConfigUsageDeprecatedAlias(t *testing.T) {
+	var listen string
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags: []*Flag{
+			DeprecatedAlias(EnvAlias(Alias(String(&listen, "listen", "LISTEN", "address to listen on"), "addr"), "ADDR"), "addr", "use -listen instead"),
+		},
+	}
+	b := &bytes.Buffer{}
+	c.FlagSet.SetOutput(b)
+	c.Usage()
+
+	expected := "address to listen on (deprecated alias -addr: use -listen instead)"
+	if !strings.Contains(b.String(), expected) {
+		t.Errorf("Config.Usage(): expected %q to contain %q", b.String(), expected)
+	}
+}
+
+func TestConfigParseDeprecatedAliasNestedFile(t *testing.T) {
+	type server struct {
+		Listen string `flag:"listen|addr!" deprecated:"use srv.listen instead"`
+	}
+	type config struct {
+		Srv server
+	}
+	v := &config{}
+	flags, err := StructToFlags(v)
+	if err != nil {
+		t.Fatalf("StructToFlags(%T): unexpected error: %s", v, err)
+	}
+	c := &Config{
+		FlagSet:            flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags:              flags,
+		Files:              []*File{JSONFile(writeTestFile(t, "config.json", `{"srv": {"addr": "x"}}`))},
+		StrictDeprecations: true,
+	}
+	c.FlagSet.SetOutput(&bytes.Buffer{})
+
+	os.Clearenv()
+	err = c.Parse([]string{})
+	var deprecated *DeprecatedError
+	if !errors.As(err, &deprecated) || deprecated.Alias != "srv-addr" {
+		t.Fatalf("Config.Parse(...): expected a *DeprecatedError for the alias srv-addr, got %v", err)
+	}
+	if v.Srv.Listen != "x" {
+		t.Errorf("Config.Parse(...): got %q, expected %q", v.Srv.Listen, "x")
+	}
+}
@@ -0,0 +1,277 @@
+package rig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// DotEnvFile creates a File for the dotenv file at `path`. Unlike the other files, the
+// variables it defines are matched against the flags' environment variable names, exactly as
+// the process environment is. By default, dotenv files are consulted right after the process
+// environment, before the other files, including the ones selected by ConfigFile flags (see
+// Config.EnvFilesSource).
+//
+// The file contains one `KEY=value` assignment per line, optionally prefixed with `export`.
+// Values can be single-quoted (taken literally), double-quoted (supporting escape sequences
+// and spanning multiple lines) or unquoted. Comments start with `#`. When a key is
+// assigned more than once, the last assignment wins.
+// `${VAR}` and `$VAR` are replaced by the value of VAR in the process environment or, if it
+// isn't set there, by a variable defined earlier in the file.
+func DotEnvFile(path string) *File {
+	return &File{
+		Path:   path,
+		env:    true,
+		decode: decodeDotEnv,
+	}
+}
+
+type dotEnvParser struct {
+	data []rune
+	pos  int
+	line int
+
+	vars map[string]string
+}
+
+func decodeDotEnv(data []byte) ([]*fileEntry, error) {
+	p := &dotEnvParser{
+		data: []rune(string(data)),
+		line: 1,
+		vars: map[string]string{},
+	}
+
+	var entries []*fileEntry
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return entries, nil
+		}
+
+		line := p.line
+		key, value, err := p.assignment()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		p.vars[key] = value
+		entries = append(entries, &fileEntry{
+			path:   []string{key},
+			line:   line,
+			values: []string{value},
+		})
+	}
+}
+
+func (p *dotEnvParser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *dotEnvParser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+
+	return p.data[p.pos]
+}
+
+func (p *dotEnvParser) next() rune {
+	r := p.peek()
+	p.pos++
+	if r == '\n' {
+		p.line++
+	}
+
+	return r
+}
+
+func (p *dotEnvParser) skipSpaces() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.next()
+	}
+}
+
+// skipBlank skips empty lines and comments.
+func (p *dotEnvParser) skipBlank() {
+	for !p.eof() {
+		switch r := p.peek(); {
+		case unicode.IsSpace(r):
+			p.next()
+		case r == '#':
+			p.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+func (p *dotEnvParser) skipLine() {
+	for !p.eof() && p.next() != '\n' {
+	}
+}
+
+// endOfLine expects nothing but spaces and an optional comment until the end of the line.
+func (p *dotEnvParser) endOfLine() error {
+	p.skipSpaces()
+	switch p.peek() {
+	case 0, '\n':
+	case '\r':
+		p.next()
+		if !p.eof() && p.peek() != '\n' {
+			return errors.New("unexpected carriage return")
+		}
+	case '#':
+	default:
+		return fmt.Errorf("unexpected character %q after value", p.peek())
+	}
+	p.skipLine()
+
+	return nil
+}
+
+func (p *dotEnvParser) assignment() (key, value string, err error) {
+	key = p.name(true)
+	if key == "export" && (p.peek() == ' ' || p.peek() == '\t') {
+		p.skipSpaces()
+		key = p.name(true)
+	}
+	if key == "" {
+		return "", "", fmt.Errorf("expected variable name, got %q", p.peek())
+	}
+
+	p.skipSpaces()
+	if p.next() != '=' {
+		return "", "", fmt.Errorf("expected '=' after %q", key)
+	}
+	p.skipSpaces()
+
+	switch p.peek() {
+	case '\'':
+		value, err = p.singleQuoted()
+	case '"':
+		value, err = p.doubleQuoted()
+	default:
+		value, err = p.unquoted()
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("%s: %w", key, err)
+	}
+
+	return key, value, p.endOfLine()
+}
+
+// name reads a variable name. Dots are only accepted in the names being assigned, so that
+// `$HOME.d` refers to HOME.
+func (p *dotEnvParser) name(allowDots bool) string {
+	start := p.pos
+	for !p.eof() {
+		r := p.peek()
+		if r != '_' && !(allowDots && r == '.') && !unicode.IsLetter(r) && !(p.pos > start && unicode.IsDigit(r)) {
+			break
+		}
+		p.next()
+	}
+
+	return string(p.data[start:p.pos])
+}
+
+func (p *dotEnvParser) singleQuoted() (string, error) {
+	p.next() // opening quote
+	b := &strings.Builder{}
+	for {
+		if p.eof() {
+			return "", errors.New("unterminated single-quoted value")
+		}
+		r := p.next()
+		if r == '\'' {
+			return b.String(), nil
+		}
+		b.WriteRune(r)
+	}
+}
+
+func (p *dotEnvParser) doubleQuoted() (string, error) {
+	p.next() // opening quote
+	b := &strings.Builder{}
+	for {
+		if p.eof() {
+			return "", errors.New("unterminated double-quoted value")
+		}
+		r := p.next()
+		switch r {
+		default:
+			b.WriteRune(r)
+		case '"':
+			return b.String(), nil
+		case '$':
+			err := p.interpolate(b)
+			if err != nil {
+				return "", err
+			}
+		case '\\':
+			switch esc := p.next(); esc {
+			default:
+				b.WriteRune('\\')
+				b.WriteRune(esc)
+			case 'n':
+				b.WriteRune('\n')
+			case 'r':
+				b.WriteRune('\r')
+			case 't':
+				b.WriteRune('\t')
+			case '"', '\\', '$':
+				b.WriteRune(esc)
+			}
+		}
+	}
+}
+
+func (p *dotEnvParser) unquoted() (string, error) {
+	b := &strings.Builder{}
+	for !p.eof() {
+		r := p.peek()
+		if r == '\n' || r == '\r' || (r == '#' && p.pos > 0 && unicode.IsSpace(p.data[p.pos-1])) {
+			break
+		}
+		p.next()
+		if r == '$' {
+			err := p.interpolate(b)
+			if err != nil {
+				return "", err
+			}
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return strings.TrimRightFunc(b.String(), unicode.IsSpace), nil
+}
+
+// interpolate replaces the reference following a '$'.
+func (p *dotEnvParser) interpolate(b *strings.Builder) error {
+	braced := p.peek() == '{'
+	if braced {
+		p.next()
+	}
+
+	name := p.name(false)
+	if braced && p.next() != '}' {
+		return errors.New("unterminated variable reference")
+	}
+	if name == "" {
+		if braced {
+			return errors.New("empty variable reference")
+		}
+		b.WriteRune('$')
+		return nil
+	}
+
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		v = p.vars[name]
+	}
+	b.WriteString(v)
+
+	return nil
+}
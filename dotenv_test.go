@@ -0,0 +1,198 @@
+package rig
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeDotEnv(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("FROM_ENV", "env")
+
+	for _, test := range []struct {
+		in       string
+		expected []*fileEntry
+	}{
+		{
+			in:       "",
+			expected: nil,
+		},
+		{
+			in: "# comment\n\nFOO=bar\nexport BAZ = qux # trailing comment\nEMPTY=\nHASH=a#b\n",
+			expected: []*fileEntry{
+				{path: []string{"FOO"}, line: 3, values: []string{"bar"}},
+				{path: []string{"BAZ"}, line: 4, values: []string{"qux"}},
+				{path: []string{"EMPTY"}, line: 5, values: []string{""}},
+				{path: []string{"HASH"}, line: 6, values: []string{"a#b"}},
+			},
+		},
+		{
+			in: "SINGLE='${FOO} \\n'\nDOUBLE=\"a\\nb \\\"c\\\" \\$FOO\" # comment\nMULTI=\"a\nb\"\nNEXT=1\n",
+			expected: []*fileEntry{
+				{path: []string{"SINGLE"}, line: 1, values: []string{"${FOO} \\n"}},
+				{path: []string{"DOUBLE"}, line: 2, values: []string{"a\nb \"c\" $FOO"}},
+				{path: []string{"MULTI"}, line: 3, values: []string{"a\nb"}},
+				{path: []string{"NEXT"}, line: 5, values: []string{"1"}},
+			},
+		},
+		{
+			in: "FOO=foo\nBAR=${FOO}-$FOO-${FROM_ENV}\nBAZ=\"$BAR.d\"\nCOST=5$\nUNSET=${NOPE}\n",
+			expected: []*fileEntry{
+				{path: []string{"FOO"}, line: 1, values: []string{"foo"}},
+				{path: []string{"BAR"}, line: 2, values: []string{"foo-foo-env"}},
+				{path: []string{"BAZ"}, line: 3, values: []string{"foo-foo-env.d"}},
+				{path: []string{"COST"}, line: 4, values: []string{"5$"}},
+				{path: []string{"UNSET"}, line: 5, values: []string{""}},
+			},
+		},
+		{
+			in: "FOO=foo\r\nBAR=bar\r\n",
+			expected: []*fileEntry{
+				{path: []string{"FOO"}, line: 1, values: []string{"foo"}},
+				{path: []string{"BAR"}, line: 2, values: []string{"bar"}},
+			},
+		},
+	} {
+		got, err := decodeDotEnv([]byte(test.in))
+		if err != nil {
+			t.Errorf("decodeDotEnv(%q): unexpected error: %s", test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("decodeDotEnv(%q) = %+v, expected %+v", test.in, got, test.expected)
+		}
+	}
+
+	for _, in := range []string{
+		"FOO\n",
+		"=bar\n",
+		"FOO='bar\n",
+		"FOO=\"bar\n",
+		"FOO=\"bar\" baz\n",
+		"FOO=${BAR\n",
+		"FOO=${}\n",
+	} {
+		_, err := decodeDotEnv([]byte(in))
+		if err == nil {
+			t.Errorf("decodeDotEnv(%q): expected error, got nil", in)
+		}
+	}
+}
+
+func TestConfigParseDotEnvFile(t *testing.T) {
+	path := writeTestFile(t, ".env", "STRING_ENV=from-dotenv\nINT_ENV=42\nexport ENV_ONLY=foo\n")
+	jsonPath := writeTestFile(t, "config.json", `{"string-flag": "from-json", "int-flag": 12}`)
+
+	t.Run("precedence", func(t *testing.T) {
+		var (
+			s  string
+			i  int
+			e  string
+			ss string
+		)
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				String(&s, "string-flag", "STRING_ENV", ""),
+				Int(&i, "int-flag", "INT_ENV", ""),
+				String(&e, "", "ENV_ONLY", ""),
+				String(&ss, "other-string", "OTHER_STRING", ""),
+			},
+			Files: []*File{DotEnvFile(path), JSONFile(jsonPath)},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		os.Setenv("STRING_ENV", "from-env")
+		err := c.Parse([]string{})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+
+		if s != "from-env" {
+			t.Errorf("STRING_ENV: got %q, expected %q", s, "from-env")
+		}
+		if i != 42 {
+			t.Errorf("INT_ENV: got %d, expected %d", i, 42)
+		}
+		if e != "foo" {
+			t.Errorf("ENV_ONLY: got %q, expected %q", e, "foo")
+		}
+		if ss != "" {
+			t.Errorf("OTHER_STRING: got %q, expected it to be left unset", ss)
+		}
+	})
+
+	t.Run("before config file", func(t *testing.T) {
+		yamlPath := writeTestFile(t, "config.yaml", "name: from-yaml\n")
+		dotEnvPath := writeTestFile(t, ".env", "NAME=from-dotenv\n")
+		var config, name string
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				ConfigFile(String(&config, "config", "", "")),
+				String(&name, "name", "NAME", ""),
+			},
+			Files: []*File{DotEnvFile(dotEnvPath)},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		err := c.Parse([]string{"-config", yamlPath})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+		if name != "from-dotenv" {
+			t.Errorf("NAME: got %q, expected %q", name, "from-dotenv")
+		}
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		var i int
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Int(&i, "", "STRING_ENV", ""),
+			},
+			Files: []*File{DotEnvFile(path)},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		err := c.Parse([]string{})
+		if err == nil {
+			t.Fatalf("Config.Parse(...): expected error, got nil")
+		}
+		for _, expected := range []string{path + ":1", `"STRING_ENV"`} {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("Config.Parse(...): expected error %q to contain %q", err, expected)
+			}
+		}
+	})
+
+	t.Run("reassigned key", func(t *testing.T) {
+		path := writeTestFile(t, ".env", "A=first\nA=second\n")
+		var a string
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				String(&a, "", "A", ""),
+			},
+			Files: []*File{DotEnvFile(path)},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		err := c.Parse([]string{})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+		if a != "second" || c.Flags[0].Origin().Line != 2 {
+			t.Errorf("A: got %q from line %d, expected %q from line %d", a, c.Flags[0].Origin().Line, "second", 2)
+		}
+	})
+}
@@ -0,0 +1,195 @@
+package rig
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// A DumpFormat is an output format for Config.Dump.
+type DumpFormat string
+
+// The formats supported by Config.Dump.
+const (
+	// DumpTable writes a human readable table.
+	DumpTable DumpFormat = "table"
+	// DumpJSON writes a JSON array, with one object per flag.
+	DumpJSON DumpFormat = "json"
+	// DumpEnv writes a dotenv file (see DotEnvFile) setting the flags' environment variables.
+	// Flags without an environment variable are omitted.
+	DumpEnv DumpFormat = "env"
+)
+
+const secretMask = "******"
+
+// ErrConfigPrinted is returned by Config.Parse after the configuration has been printed
+// because of a PrintConfig flag, when the FlagSet's ErrorHandling is flag.ContinueOnError.
+var ErrConfigPrinted = errors.New("configuration printed")
+
+type dumpedFlag struct {
+	Flag    string `json:"flag,omitempty"`
+	Env     string `json:"env,omitempty"`
+	Value   string `json:"value"`
+	Default string `json:"default"`
+	Origin  string `json:"origin"`
+	Secret  bool   `json:"secret,omitempty"`
+}
+
+func dumpFlag(f *Flag) dumpedFlag {
+	d := dumpedFlag{
+		Flag:    f.Name,
+		Env:     f.Env,
+		Value:   f.Value.String(),
+		Default: f.defaultValue,
+		Origin:  f.Origin().String(),
+		Secret:  f.Secret,
+	}
+	if f.Secret {
+		d.Value = maskSecret(d.Value)
+		d.Default = maskSecret(d.Default)
+	}
+
+	return d
+}
+
+func maskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+
+	return secretMask
+}
+
+// Dump writes the current value, default value and origin of every flag to w, in the format
+// provided. The values of Secret flags are masked.
+func (c *Config) Dump(w io.Writer, format DumpFormat) error {
+	c.setDefaultValues()
+
+	flags := []dumpedFlag{}
+	for _, f := range c.Flags {
+		if f.Name == "" && f.Env == "" {
+			continue
+		}
+		flags = append(flags, dumpFlag(f))
+	}
+
+	switch format {
+	default:
+		return fmt.Errorf("unknown dump format %q", format)
+	case DumpTable:
+		dumpTable(w, flags)
+		return nil
+	case DumpJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(flags)
+	case DumpEnv:
+		return dumpEnv(w, flags)
+	}
+}
+
+func dumpTable(w io.Writer, flags []dumpedFlag) {
+	lines := make([][]string, 0, len(flags)+1)
+	lines = append(lines, []string{"FLAG", "ENV", "VALUE", "DEFAULT", "ORIGIN"})
+	for _, f := range flags {
+		name := ""
+		if f.Flag != "" {
+			name = "-" + f.Flag
+		}
+		lines = append(lines, []string{name, f.Env, f.Value, f.Default, f.Origin})
+	}
+
+	printUsageLines(w, lines, 0, 2)
+}
+
+func dumpEnv(w io.Writer, flags []dumpedFlag) error {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`, "\r", `\r`)
+	for _, f := range flags {
+		if f.Env == "" {
+			continue
+		}
+
+		_, err := fmt.Fprintf(w, "%s=\"%s\" # %s\n", f.Env, replacer.Replace(f.Value), f.Origin)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type printConfigValue struct {
+	format *DumpFormat
+}
+
+func (v printConfigValue) String() string {
+	if v.format == nil {
+		return ""
+	}
+
+	return string(*v.format)
+}
+
+func (v printConfigValue) Set(s string) error {
+	switch format := DumpFormat(strings.ToLower(s)); format {
+	default:
+		return fmt.Errorf("unknown format %q, expected %q, %q or %q", s, DumpTable, DumpJSON, DumpEnv)
+	case "true":
+		*v.format = DumpTable
+	case "false":
+		*v.format = ""
+	case DumpTable, DumpJSON, DumpEnv:
+		*v.format = format
+	}
+
+	return nil
+}
+
+func (v printConfigValue) IsBoolFlag() bool {
+	return true
+}
+
+// PrintConfig creates a flag that makes Config.Parse print the configuration (see Config.Dump)
+// to the FlagSet's output once every flag has been resolved, then exit.
+// The flag can be used as a boolean flag to print a table, or be given the format to use
+// (`-print-config=json`).
+func PrintConfig(flag, env, usage string) *Flag {
+	return &Flag{
+		Value: printConfigValue{
+			format: new(DumpFormat),
+		},
+		Name:     flag,
+		Env:      env,
+		Usage:    usage,
+		TypeHint: "format",
+	}
+}
+
+// printConfig prints the configuration if a PrintConfig flag was set.
+func (c *Config) printConfig() error {
+	for _, f := range c.Flags {
+		v, ok := f.Value.(printConfigValue)
+		if !ok || *v.format == "" {
+			continue
+		}
+
+		err := c.Dump(c.FlagSet.Output(), *v.format)
+		if err != nil {
+			return err
+		}
+
+		switch c.FlagSet.ErrorHandling() {
+		case flag.ExitOnError:
+			os.Exit(0)
+		case flag.PanicOnError:
+			panic(ErrConfigPrinted)
+		}
+		return ErrConfigPrinted
+	}
+
+	return nil
+}
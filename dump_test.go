@@ -0,0 +1,176 @@
+package rig
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func dumpTestConfig(t *testing.T) *Config {
+	t.Helper()
+
+	var (
+		s     = "default"
+		i     int
+		token string
+		ss    []string
+	)
+	tokenFlag := String(&token, "token", "TOKEN", "")
+	tokenFlag.Secret = true
+
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags: []*Flag{
+			String(&s, "string-flag", "STRING_ENV", ""),
+			Int(&i, "int-flag", "", ""),
+			tokenFlag,
+			Repeatable(&ss, StringGenerator(), "", "STRINGS", ""),
+		},
+	}
+	c.FlagSet.SetOutput(&bytes.Buffer{})
+
+	os.Clearenv()
+	os.Setenv("TOKEN", "hunter2")
+	os.Setenv("STRINGS", `a,"b $c"`)
+	err := c.Parse([]string{"-int-flag=42"})
+	if err != nil {
+		t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+	}
+
+	return c
+}
+
+func TestConfigDump(t *testing.T) {
+	t.Run("table", func(t *testing.T) {
+		c := dumpTestConfig(t)
+		buf := &bytes.Buffer{}
+
+		err := c.Dump(buf, DumpTable)
+		if err != nil {
+			t.Fatalf("Config.Dump(DumpTable): unexpected error: %s", err)
+		}
+
+		out := buf.String()
+		for _, expected := range []string{"FLAG", "-string-flag", "STRING_ENV", "default", "-int-flag", "42", "command line flag -int-flag", `env variable "TOKEN"`, secretMask} {
+			if !strings.Contains(out, expected) {
+				t.Errorf("Config.Dump(DumpTable): expected to find %q in %q", expected, out)
+			}
+		}
+		if strings.Contains(out, "hunter2") {
+			t.Errorf("Config.Dump(DumpTable): secret value leaked in %q", out)
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		c := dumpTestConfig(t)
+		buf := &bytes.Buffer{}
+
+		err := c.Dump(buf, DumpJSON)
+		if err != nil {
+			t.Fatalf("Config.Dump(DumpJSON): unexpected error: %s", err)
+		}
+
+		var got []dumpedFlag
+		err = json.Unmarshal(buf.Bytes(), &got)
+		if err != nil {
+			t.Fatalf("Config.Dump(DumpJSON): invalid JSON output: %s", err)
+		}
+		expected := []dumpedFlag{
+			{Flag: "string-flag", Env: "STRING_ENV", Value: "default", Default: "default", Origin: "default value"},
+			{Flag: "int-flag", Value: "42", Default: "0", Origin: "command line flag -int-flag"},
+			{Flag: "token", Env: "TOKEN", Value: secretMask, Origin: `env variable "TOKEN"`, Secret: true},
+			{Env: "STRINGS", Value: `[a,"b $c"]`, Default: "[]", Origin: `env variable "STRINGS"`},
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Config.Dump(DumpJSON) = %+v, expected %+v", got, expected)
+		}
+	})
+
+	t.Run("env", func(t *testing.T) {
+		c := dumpTestConfig(t)
+		buf := &bytes.Buffer{}
+
+		err := c.Dump(buf, DumpEnv)
+		if err != nil {
+			t.Fatalf("Config.Dump(DumpEnv): unexpected error: %s", err)
+		}
+
+		entries, err := decodeDotEnv(buf.Bytes())
+		if err != nil {
+			t.Fatalf("Config.Dump(DumpEnv): invalid dotenv output %q: %s", buf, err)
+		}
+		got := map[string]string{}
+		for _, e := range entries {
+			got[e.key()] = e.values[0]
+		}
+		expected := map[string]string{
+			"STRING_ENV": "default",
+			"TOKEN":      secretMask,
+			"STRINGS":    `[a,"b $c"]`,
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Config.Dump(DumpEnv) = %q, expected %q", got, expected)
+		}
+	})
+
+	t.Run("unknown format", func(t *testing.T) {
+		c := dumpTestConfig(t)
+
+		err := c.Dump(&bytes.Buffer{}, "yaml")
+		if err == nil {
+			t.Errorf("Config.Dump(%q): expected error, got nil", "yaml")
+		}
+	})
+}
+
+func TestPrintConfig(t *testing.T) {
+	for _, test := range []struct {
+		args     []string
+		err      error
+		expected string
+	}{
+		{args: []string{}, err: nil, expected: ""},
+		{args: []string{"-print-config"}, err: ErrConfigPrinted, expected: "ORIGIN"},
+		{args: []string{"-print-config=json"}, err: ErrConfigPrinted, expected: `"origin"`},
+		{args: []string{"-print-config=false"}, err: nil, expected: ""},
+	} {
+		var s string
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				String(&s, "string-flag", "STRING_ENV", ""),
+				PrintConfig("print-config", "", "print the configuration"),
+			},
+		}
+		buf := &bytes.Buffer{}
+		c.FlagSet.SetOutput(buf)
+
+		os.Clearenv()
+		err := c.Parse(test.args)
+		if err != test.err {
+			t.Errorf("Config.Parse(%q) = %v, expected %v", test.args, err, test.err)
+		}
+		if test.expected == "" && buf.Len() != 0 {
+			t.Errorf("Config.Parse(%q): expected no output, got %q", test.args, buf)
+		}
+		if !strings.Contains(buf.String(), test.expected) {
+			t.Errorf("Config.Parse(%q): expected output to contain %q, got %q", test.args, test.expected, buf)
+		}
+	}
+
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags: []*Flag{
+			PrintConfig("print-config", "", "print the configuration"),
+		},
+	}
+	c.FlagSet.SetOutput(&bytes.Buffer{})
+	err := c.Parse([]string{"-print-config=xml"})
+	if err == nil {
+		t.Errorf("Config.Parse(%q): expected error, got nil", "-print-config=xml")
+	}
+}
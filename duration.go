@@ -21,7 +21,7 @@ func (v durationValidators) Set(s string) error {
 	for _, validator := range v.validators {
 		err = validator(time.Duration(*v.durationValue))
 		if err != nil {
-			return err
+			return &ValidationError{Value: s, Err: err}
 		}
 	}
 
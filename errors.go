@@ -0,0 +1,267 @@
+package rig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// A ParseError aggregates the errors found by Config.Parse once the command line has been
+// parsed: invalid values coming from any source, missing required flags, and the groups and
+// rules that are not satisfied. errors.Is and errors.As look through every error it holds.
+type ParseError struct {
+	Errors []error
+}
+
+func (e *ParseError) Error() string {
+	return joinErrors(e.Errors)
+}
+
+// Is reports whether any of the errors matches target.
+func (e *ParseError) Is(target error) bool {
+	return anyErrorIs(e.Errors, target)
+}
+
+// As finds the first of the errors that matches target.
+func (e *ParseError) As(target interface{}) bool {
+	return anyErrorAs(e.Errors, target)
+}
+
+// add appends an error, flattening the ParseErrors.
+func (e *ParseError) add(err error) {
+	switch err := err.(type) {
+	case nil:
+	case *ParseError:
+		e.Errors = append(e.Errors, err.Errors...)
+	default:
+		e.Errors = append(e.Errors, err)
+	}
+}
+
+// failed reports whether an error was already reported for the flag provided: a value was
+// rejected or couldn't be looked up, or it is missing values.
+func (e *ParseError) failed(f *Flag) bool {
+	for _, err := range e.Errors {
+		switch err := err.(type) {
+		case *InvalidValueError:
+			if err.Flag == f {
+				return true
+			}
+		case *MissingRequiredError:
+			if err.Flag == f {
+				return true
+			}
+		case *SourceError:
+			for _, flag := range err.Flags {
+				if flag == f {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func (e *ParseError) errorOrNil() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// An InvalidValueError is returned when a flag rejects the value provided by the command line
+// or one of the Sources.
+type InvalidValueError struct {
+	Flag *Flag
+	// Origin describes where the value came from, Origin.Raw holding the raw input, masked for
+	// the Secret flags as by Flag.Origin. Origin.Kind is empty when the Source didn't describe
+	// it.
+	Origin Origin
+	// Err is the cause of the error. It wraps a ValidationError when a validator rejected the
+	// value.
+	Err error
+}
+
+func (e *InvalidValueError) Error() string {
+	if e.Origin.Kind == "" {
+		return fmt.Sprintf("invalid value for %s: %s", flagDisplayName(e.Flag), e.Err)
+	}
+
+	return invalidValueError(e.Flag, e.Origin, e.Err).Error()
+}
+
+func (e *InvalidValueError) Unwrap() error {
+	return e.Err
+}
+
+// newInvalidValueError masks the raw value of the Secret flags, as Flag.Origin does.
+func newInvalidValueError(f *Flag, origin Origin, err error) *InvalidValueError {
+	if f.Secret {
+		origin.Raw = maskSecret(origin.Raw)
+	}
+
+	return &InvalidValueError{Flag: f, Origin: origin, Err: err}
+}
+
+// A SourceError is returned when a Source fails to look values up. It is returned once for
+// all the flags the Source failed the same way for.
+type SourceError struct {
+	// Flags are the flags that were being looked up.
+	Flags []*Flag
+	Err   error
+}
+
+func (e *SourceError) Error() string {
+	names := make([]string, 0, len(e.Flags))
+	for _, f := range e.Flags {
+		names = append(names, flagDisplayName(f))
+	}
+
+	return fmt.Sprintf("looking up %s: %s", joinWords(names, "and"), e.Err)
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// A MissingRequiredError is returned for each Required flag that wasn't set by any source, and
+// for each positional flag that got fewer values than its minimum arity (see Arity).
+type MissingRequiredError struct {
+	Flag *Flag
+	// Got is the number of values of a positional flag with a minimum arity.
+	Got int
+}
+
+func (e *MissingRequiredError) Error() string {
+	if e.Flag.Positional && e.Flag.minArgs > 0 {
+		return fmt.Sprintf("missing values for positional argument %s: expected at least %d, got %d", positionalName(e.Flag), e.Flag.minArgs, e.Got)
+	}
+
+	return e.Flag.missingError().Error()
+}
+
+// An UnexpectedPositionalError is returned for the first positional argument that no
+// positional flag can accept.
+type UnexpectedPositionalError struct {
+	// Flag is the last positional flag, which doesn't accept any more values.
+	Flag *Flag
+	// Raw is the unexpected argument, masked when Flag is Secret.
+	Raw string
+}
+
+func (e *UnexpectedPositionalError) Error() string {
+	switch {
+	case isSliceFlag(e.Flag) && e.Flag.Secret:
+		return fmt.Sprintf("too many values for positional argument %s: expected at most %d", positionalName(e.Flag), e.Flag.maxArgs)
+	case isSliceFlag(e.Flag):
+		return fmt.Sprintf("too many values for positional argument %s: expected at most %d, got %q", positionalName(e.Flag), e.Flag.maxArgs, e.Raw)
+	case e.Flag.Secret:
+		return fmt.Sprintf("unexpected positional argument after %s", positionalName(e.Flag))
+	}
+
+	return fmt.Sprintf("unexpected positional argument %q after %s", e.Raw, positionalName(e.Flag))
+}
+
+// newUnexpectedPositionalError masks the argument when the flag is Secret.
+func newUnexpectedPositionalError(f *Flag, raw string) *UnexpectedPositionalError {
+	if f.Secret {
+		raw = maskSecret(raw)
+	}
+
+	return &UnexpectedPositionalError{Flag: f, Raw: raw}
+}
+
+// An UnknownFlagError is returned when the command line holds a flag that isn't defined.
+type UnknownFlagError struct {
+	// Arg is the flag as given on the command line, as in "-flag-a".
+	Arg string
+	// Suggestions are the defined flags closest to Arg, the closest first.
+	Suggestions []string
+}
+
+func (e *UnknownFlagError) Error() string {
+	return "flag provided but not defined: " + e.Arg + didYouMean(e.Suggestions)
+}
+
+// An UnknownEnvError is returned for each environment variable starting with Config.EnvPrefix
+// that doesn't match any flag, as such variables are likely typos. They are only reported when
+// parsing fails for another reason, unless Config.StrictEnv is set.
+type UnknownEnvError struct {
+	Name string
+	// Suggestions are the environment variables of the flags closest to Name, the closest first.
+	Suggestions []string
+}
+
+func (e *UnknownEnvError) Error() string {
+	return "unknown environment variable " + e.Name + didYouMean(e.Suggestions)
+}
+
+// A ValidationError is returned when a validator rejects a value, or when the Validate method
+// of a struct fails (see ValidateStruct).
+type ValidationError struct {
+	// Value is the raw value rejected by a validator, masked for the Secret flags. It is empty
+	// for the struct validations.
+	Value string
+	// Path is the path of the nested struct whose Validate method failed, as in ".Bar". It is
+	// empty for the validators and the top-level struct.
+	Path string
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path != "" {
+		return e.Path + ": " + e.Err.Error()
+	}
+
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// errorList aggregates several errors, one per line.
+type errorList []error
+
+func (l errorList) Error() string {
+	return joinErrors(l)
+}
+
+func (l errorList) Is(target error) bool {
+	return anyErrorIs(l, target)
+}
+
+func (l errorList) As(target interface{}) bool {
+	return anyErrorAs(l, target)
+}
+
+func joinErrors(errs []error) string {
+	ss := make([]string, 0, len(errs))
+	for _, err := range errs {
+		ss = append(ss, err.Error())
+	}
+
+	return strings.Join(ss, "\n")
+}
+
+func anyErrorIs(errs []error, target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func anyErrorAs(errs []error, target interface{}) bool {
+	for _, err := range errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
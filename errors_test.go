@@ -0,0 +1,153 @@
+package rig
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConfigParseAggregatedErrors(t *testing.T) {
+	errEmpty := errors.New("must not be empty")
+	var (
+		i, j     int
+		s, req   string
+		position int
+	)
+	intFlag := Int(&i, "int-flag", "INT_ENV", "")
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags: []*Flag{
+			intFlag,
+			Required(Int(&j, "other-int", "OTHER_INT_ENV", "")),
+			String(&s, "string-flag", "STRING_ENV", "", func(s string) error {
+				if s == "" {
+					return errEmpty
+				}
+				return nil
+			}),
+			Required(String(&req, "required", "REQUIRED_ENV", "")),
+			Positional(Int(&position, "position", "", "")),
+		},
+	}
+	b := &bytes.Buffer{}
+	c.FlagSet.SetOutput(b)
+
+	os.Clearenv()
+	os.Setenv("INT_ENV", "foo")
+	os.Setenv("OTHER_INT_ENV", "bar")
+	os.Setenv("STRING_ENV", "")
+	err := c.Parse([]string{"baz"})
+	if err == nil {
+		t.Fatalf("Config.Parse(...): expected error, got nil")
+	}
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("Config.Parse(...): expected a *ParseError, got %T", err)
+	}
+	expected := []string{
+		`invalid value "foo" for env variable "INT_ENV"`,
+		`invalid value "bar" for env variable "OTHER_INT_ENV"`,
+		`invalid value "" for env variable "STRING_ENV": must not be empty`,
+		`invalid value "baz" for positional argument position`,
+		"missing command line flag -required or environment variable REQUIRED_ENV",
+	}
+	if len(parseErr.Errors) != len(expected) {
+		t.Fatalf("Config.Parse(...): got %d errors, expected %d: %q", len(parseErr.Errors), len(expected), err)
+	}
+	for k, e := range parseErr.Errors {
+		if !strings.Contains(e.Error(), expected[k]) {
+			t.Errorf("Config.Parse(...): error %d = %q, expected it to contain %q", k, e, expected[k])
+		}
+	}
+
+	var invalid *InvalidValueError
+	if !errors.As(err, &invalid) || invalid.Flag != intFlag || invalid.Origin.Kind != OriginEnv || invalid.Origin.Raw != "foo" {
+		t.Errorf("Config.Parse(...): expected the first *InvalidValueError to concern -int-flag from the environment, got %+v", invalid)
+	}
+	var validation *ValidationError
+	if !errors.As(err, &validation) || validation.Value != "" || validation.Err != errEmpty {
+		t.Errorf("Config.Parse(...): expected a *ValidationError wrapping the validator's error, got %+v", validation)
+	}
+	if !errors.Is(err, errEmpty) {
+		t.Errorf("Config.Parse(...): expected errors.Is to find the validator's error")
+	}
+	var missing *MissingRequiredError
+	if !errors.As(err, &missing) || missing.Flag.Name != "required" {
+		t.Errorf("Config.Parse(...): expected a *MissingRequiredError for -required, got %+v", missing)
+	}
+
+	output := b.String()
+	usage := strings.Index(output, "Usage of")
+	for _, e := range expected {
+		k := strings.Index(output, e)
+		if k < 0 || k > usage {
+			t.Errorf("Config.Parse(...): expected %q to be printed before the usage, got %q", e, output)
+		}
+	}
+}
+
+func TestConfigParseTypedErrors(t *testing.T) {
+	var (
+		n        int
+		position string
+	)
+	nFlag := Int(&n, "n", "", "")
+	positional := Positional(String(&position, "position", "", ""))
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags:   []*Flag{nFlag, positional},
+	}
+	c.FlagSet.SetOutput(&bytes.Buffer{})
+
+	os.Clearenv()
+	err := c.Parse([]string{"-n", "foo", "a", "b"})
+	if err == nil {
+		t.Fatalf("Config.Parse(...): expected error, got nil")
+	}
+
+	var invalid *InvalidValueError
+	if !errors.As(err, &invalid) || invalid.Flag != nFlag || invalid.Origin.Kind != OriginFlag || invalid.Origin.Raw != "foo" {
+		t.Errorf("Config.Parse(...): expected an *InvalidValueError for -n from the command line, got %+v", invalid)
+	}
+	var unexpected *UnexpectedPositionalError
+	if !errors.As(err, &unexpected) || unexpected.Flag != positional || unexpected.Raw != "b" {
+		t.Errorf("Config.Parse(...): expected an *UnexpectedPositionalError for %q, got %+v", "b", unexpected)
+	}
+}
+
+func TestValidationErrorError(t *testing.T) {
+	for _, test := range []struct {
+		err      *ValidationError
+		expected string
+	}{
+		{
+			err:      &ValidationError{Value: "foo", Err: errors.New("test")},
+			expected: "test",
+		},
+		{
+			err:      &ValidationError{Path: ".Bar", Err: errors.New("test")},
+			expected: ".Bar: test",
+		},
+	} {
+		if got := test.err.Error(); got != test.expected {
+			t.Errorf("%+v.Error() = %q, expected %q", test.err, got, test.expected)
+		}
+	}
+}
+
+func TestSourceErrorError(t *testing.T) {
+	var a, b string
+	err := &SourceError{
+		Flags: []*Flag{String(&a, "a", "", ""), String(&b, "", "B", "")},
+		Err:   errors.New("test"),
+	}
+
+	expected := "looking up -a and B: test"
+	if got := err.Error(); got != expected {
+		t.Errorf("%+v.Error() = %q, expected %q", err, got, expected)
+	}
+}
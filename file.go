@@ -0,0 +1,315 @@
+package rig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// A File is a configuration file consulted by Config.Parse for the flags that were not
+// set from the command line or the environment. Files can also be used directly as a Source.
+//
+// The keys of the document are matched against the flags' names. Nested objects map onto
+// the prefixes generated by StructToFlags for nested structs: `{"bar": {"flag-e": 3}}` sets
+// the flag "bar-flag-e".
+type File struct {
+	Path string
+	// Optional files are ignored when they do not exist.
+	Optional bool
+
+	env     bool // keys are matched against the flags' environment variables
+	decode  decodeFunc
+	entries map[string]*fileEntry
+	loaded  bool
+}
+
+type decodeFunc func(data []byte) ([]*fileEntry, error)
+
+type fileEntry struct {
+	path   []string
+	line   int
+	values []string
+	list   bool
+}
+
+func (e *fileEntry) name() string {
+	return strings.Join(e.path, "-")
+}
+
+func (e *fileEntry) key() string {
+	return strings.Join(e.path, ".")
+}
+
+// JSONFile creates a File for the JSON document at `path`.
+func JSONFile(path string) *File {
+	return &File{
+		Path:   path,
+		decode: decodeJSON,
+	}
+}
+
+// NewFile creates a File for `path`, picking its format based on the extension: ".json",
+// ".yaml" or ".yml", ".toml" and ".env".
+func NewFile(path string) (*File, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	default:
+		return nil, fmt.Errorf("unsupported config file extension for %q", path)
+	case ".json":
+		return JSONFile(path), nil
+	case ".yaml", ".yml":
+		return YAMLFile(path), nil
+	case ".toml":
+		return TOMLFile(path), nil
+	case ".env":
+		return DotEnvFile(path), nil
+	}
+}
+
+func (f *File) load() error {
+	if f.loaded {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(f.Path)
+	if err != nil && !(f.Optional && os.IsNotExist(err)) {
+		return fmt.Errorf("reading config file %q: %w", f.Path, err)
+	}
+
+	entries, err := f.decode(data)
+	if err != nil {
+		return fmt.Errorf("parsing config file %q: %w", f.Path, err)
+	}
+
+	f.entries = make(map[string]*fileEntry, len(entries))
+	for _, e := range entries {
+		if prev, ok := f.entries[e.name()]; ok && !f.env { // the later assignments win in env files
+			return fmt.Errorf("parsing config file %q: key %q conflicts with key %q", f.Path, e.key(), prev.key())
+		}
+		f.entries[e.name()] = e
+	}
+	f.loaded = true
+
+	return nil
+}
+
+// Lookup implements the Source interface. The file is loaded the first time it is called.
+// The entries are looked up by the flag's Name, followed by its Aliases, or by its Env followed
+// by its EnvAliases for the env files. The flags generated by StructToFlags for nested structs
+// are matched against the nested keys, whatever the separator used for their names.
+func (f *File) Lookup(flag *Flag) (SourceValue, bool, error) {
+	err := f.load()
+	if err != nil {
+		return SourceValue{}, false, err
+	}
+
+	names := flag.names()
+	if len(flag.keyPath) > 0 { // the flags of nested structs, whatever StructOptions.FlagSeparator
+		names = append([]string{strings.Join(flag.keyPath, "-")}, names...)
+	}
+	if f.env {
+		names = flag.envNames()
+	}
+	var e *fileEntry
+	for _, name := range names {
+		if e = f.entries[name]; e != nil {
+			break
+		}
+	}
+	if e == nil {
+		return SourceValue{}, false, nil
+	}
+
+	origin := Origin{
+		Kind: OriginFile,
+		Name: e.key(),
+		File: f.Path,
+		Line: e.line,
+	}
+	if f.env {
+		origin.Kind = OriginEnv
+	}
+
+	return SourceValue{
+		Values: e.values,
+		List:   e.list,
+		Origin: origin,
+	}, true, nil
+}
+
+// loadFiles loads the files selected by the ConfigFile flags provided, followed by Config.Files.
+// The dotenv files are set apart, to be consulted before the others (see EnvFilesSource). The
+// files are only loaded when the Sources consult them.
+func (c *Config) loadFiles(flags []*Flag) error {
+	consulted := map[bool]bool{} // by File.env
+	for _, source := range c.sources() {
+		if s, ok := source.(filesSource); ok {
+			consulted[s.env] = true
+		}
+	}
+
+	c.files = nil
+	c.envFiles = nil
+	for _, f := range flags {
+		if !f.configFile {
+			continue
+		}
+
+		for _, path := range configFilePaths(f) {
+			file, err := NewFile(path)
+			if err != nil {
+				return fmt.Errorf("invalid value %q for flag %s: %w", path, flagDisplayName(f), err)
+			}
+			file.Optional = !f.set
+			c.files = append(c.files, file)
+		}
+	}
+	files := append(c.files, c.Files...)
+
+	c.files = nil
+	for _, file := range files {
+		if !consulted[file.env] {
+			continue
+		}
+		err := file.load()
+		if err != nil {
+			return err
+		}
+		if file.env {
+			c.envFiles = append(c.envFiles, file)
+			continue
+		}
+		c.files = append(c.files, file)
+	}
+
+	return nil
+}
+
+func configFilePaths(f *Flag) []string {
+	value := unwrapValue(f.Value)
+	if p, ok := value.(*pointerFlag); ok && p.Value.IsNil() {
+		return nil
+	}
+
+	sv, ok := value.(sliceValue)
+	if !ok {
+		if value.String() == "" {
+			return nil
+		}
+		return []string{value.String()}
+	}
+
+	values := reflect.Indirect(sv.value)
+	paths := make([]string, 0, values.Len())
+	for i := 0; i < values.Len(); i++ {
+		paths = append(paths, fmt.Sprint(values.Index(i)))
+	}
+
+	return paths
+}
+
+func decodeJSON(data []byte) ([]*fileEntry, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, errors.New("expected a JSON object at the top level")
+	}
+
+	var entries []*fileEntry
+	err = decodeJSONObject(dec, data, nil, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = dec.Token()
+	if err != io.EOF {
+		return nil, errors.New("unexpected data after the top level object")
+	}
+
+	return entries, nil
+}
+
+func decodeJSONObject(dec *json.Decoder, data []byte, path []string, entries *[]*fileEntry) error {
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string) // object keys are always strings
+		keyPath := append(append([]string{}, path...), key)
+		line := lineAt(data, dec.InputOffset())
+
+		tok, err = dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			err = decodeJSONObject(dec, data, keyPath, entries)
+		case json.Delim('['):
+			err = decodeJSONArray(dec, keyPath, line, entries)
+		case nil:
+			continue
+		default:
+			*entries = append(*entries, &fileEntry{
+				path:   keyPath,
+				line:   line,
+				values: []string{fmt.Sprint(tok)},
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err := dec.Token() // closing '}'
+	return err
+}
+
+func decodeJSONArray(dec *json.Decoder, path []string, line int, entries *[]*fileEntry) error {
+	e := &fileEntry{
+		path:   path,
+		line:   line,
+		values: []string{},
+		list:   true,
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if _, ok := tok.(json.Delim); ok || tok == nil {
+			return fmt.Errorf("key %q: arrays can only contain strings, numbers and booleans", e.key())
+		}
+		e.values = append(e.values, fmt.Sprint(tok))
+	}
+
+	_, err := dec.Token() // closing ']'
+	if err != nil {
+		return err
+	}
+	*entries = append(*entries, e)
+
+	return nil
+}
+
+func lineAt(data []byte, offset int64) int {
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
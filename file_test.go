@@ -0,0 +1,403 @@
+package rig
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "rig-test")
+	if err != nil {
+		t.Fatalf("creating temporary directory: %s", err)
+	}
+	t.Cleanup(func() {
+		os.RemoveAll(dir)
+	})
+
+	path := filepath.Join(dir, name)
+	err = ioutil.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatalf("writing %q: %s", path, err)
+	}
+
+	return path
+}
+
+func TestDecodeJSON(t *testing.T) {
+	for _, test := range []struct {
+		in       string
+		expected []*fileEntry
+	}{
+		{
+			in:       "",
+			expected: nil,
+		},
+		{
+			in: `{"string-flag": "foo", "int-flag": 42, "bool-flag": true, "null-flag": null}`,
+			expected: []*fileEntry{
+				{path: []string{"string-flag"}, line: 1, values: []string{"foo"}},
+				{path: []string{"int-flag"}, line: 1, values: []string{"42"}},
+				{path: []string{"bool-flag"}, line: 1, values: []string{"true"}},
+			},
+		},
+		{
+			in: "{\n  \"bar\": {\n    \"flag-e\": 3\n  },\n  \"ints\": [1, 2]\n}",
+			expected: []*fileEntry{
+				{path: []string{"bar", "flag-e"}, line: 3, values: []string{"3"}},
+				{path: []string{"ints"}, line: 5, values: []string{"1", "2"}, list: true},
+			},
+		},
+	} {
+		got, err := decodeJSON([]byte(test.in))
+		if err != nil {
+			t.Errorf("decodeJSON(%q): unexpected error: %s", test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("decodeJSON(%q) = %+v, expected %+v", test.in, got, test.expected)
+		}
+	}
+
+	for _, in := range []string{
+		`[]`,
+		`{"foo": `,
+		`{"foo": [{"bar": 1}]}`,
+		`{"foo": 1} {}`,
+	} {
+		_, err := decodeJSON([]byte(in))
+		if err == nil {
+			t.Errorf("decodeJSON(%q): expected error, got nil", in)
+		}
+	}
+}
+
+func TestConfigParseJSONFile(t *testing.T) {
+	path := writeTestFile(t, "config.json", `{
+  "string-flag": "from-file",
+  "int-flag": 42,
+  "bar": {"flag-e": 3},
+  "strings": ["a,b", "c"]
+}`)
+
+	t.Run("precedence", func(t *testing.T) {
+		var (
+			s  string
+			i  int
+			e  int
+			ss []string
+		)
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				String(&s, "string-flag", "STRING_ENV", ""),
+				Int(&i, "int-flag", "INT_ENV", ""),
+				Int(&e, "bar-flag-e", "BAR_FLAG_E", ""),
+				Repeatable(&ss, StringGenerator(), "strings", "STRINGS", ""),
+			},
+			Files: []*File{JSONFile(path)},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		os.Setenv("INT_ENV", "12")
+		err := c.Parse([]string{"-string-flag=from-args"})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+
+		if s != "from-args" {
+			t.Errorf("-string-flag: got %q, expected %q", s, "from-args")
+		}
+		if i != 12 {
+			t.Errorf("-int-flag: got %d, expected %d", i, 12)
+		}
+		if e != 3 {
+			t.Errorf("-bar-flag-e: got %d, expected %d", e, 3)
+		}
+		expectedStrings := []string{"a,b", "c"}
+		if !reflect.DeepEqual(ss, expectedStrings) {
+			t.Errorf("-strings: got %q, expected %q", ss, expectedStrings)
+		}
+		if raw := c.Flags[3].Origin().Raw; raw != "a,b,c" {
+			t.Errorf("-strings: got raw value %q, expected %q", raw, "a,b,c")
+		}
+		visited := false
+		c.FlagSet.Visit(func(f *flag.Flag) {
+			visited = visited || f.Name == "strings"
+		})
+		if !visited {
+			t.Errorf("FlagSet.Visit(...): expected -strings to be visited")
+		}
+	})
+
+	t.Run("secret list", func(t *testing.T) {
+		var ss []string
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Secret(Repeatable(&ss, StringGenerator(), "strings", "", "")),
+			},
+			Files: []*File{JSONFile(path)},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		err := c.Parse([]string{})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+		expectedStrings := []string{"a,b", "c"}
+		if !reflect.DeepEqual(ss, expectedStrings) {
+			t.Errorf("-strings: got %q, expected %q", ss, expectedStrings)
+		}
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		var s int
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Int(&s, "string-flag", "", ""),
+			},
+			Files: []*File{JSONFile(path)},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		err := c.Parse([]string{})
+		if err == nil {
+			t.Fatalf("Config.Parse(...): expected error, got nil")
+		}
+		for _, expected := range []string{path + ":2", `"string-flag"`, "-string-flag"} {
+			if !strings.Contains(err.Error(), expected) {
+				t.Errorf("Config.Parse(...): expected error %q to contain %q", err, expected)
+			}
+		}
+	})
+
+	t.Run("list for a single value", func(t *testing.T) {
+		var s string
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				String(&s, "strings", "", ""),
+			},
+			Files: []*File{JSONFile(path)},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		err := c.Parse([]string{})
+		if err == nil {
+			t.Errorf("Config.Parse(...): expected error, got nil")
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		var s string
+		missing := filepath.Join(filepath.Dir(path), "missing.json")
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				String(&s, "string-flag", "", ""),
+			},
+			Files: []*File{JSONFile(missing)},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		err := c.Parse([]string{})
+		if err == nil {
+			t.Errorf("Config.Parse(...): expected error, got nil")
+		}
+
+		optional := JSONFile(missing)
+		optional.Optional = true
+		c = &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				String(&s, "string-flag", "", ""),
+			},
+			Files: []*File{optional},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		err = c.Parse([]string{})
+		if err != nil {
+			t.Errorf("Config.Parse(...): unexpected error: %s", err)
+		}
+	})
+}
+
+func TestNewFile(t *testing.T) {
+	for _, test := range []struct {
+		path     string
+		expected *File
+	}{
+		{path: "config.json", expected: JSONFile("config.json")},
+		{path: "config.YAML", expected: YAMLFile("config.YAML")},
+		{path: "config.yml", expected: YAMLFile("config.yml")},
+		{path: "config.toml", expected: TOMLFile("config.toml")},
+		{path: ".env", expected: DotEnvFile(".env")},
+		{path: "local.env", expected: DotEnvFile("local.env")},
+	} {
+		got, err := NewFile(test.path)
+		if err != nil {
+			t.Errorf("NewFile(%q): unexpected error: %s", test.path, err)
+			continue
+		}
+		if got.Path != test.expected.Path || got.env != test.expected.env || reflect.ValueOf(got.decode).Pointer() != reflect.ValueOf(test.expected.decode).Pointer() {
+			t.Errorf("NewFile(%q) = %+v, expected %+v", test.path, got, test.expected)
+		}
+	}
+
+	_, err := NewFile("config.ini")
+	if err == nil {
+		t.Errorf("NewFile(%q): expected error, got nil", "config.ini")
+	}
+}
+
+func TestConfigParseConfigFileFlag(t *testing.T) {
+	jsonPath := writeTestFile(t, "config.json", `{"string-flag": "from-json", "int-flag": 12}`)
+	yamlPath := writeTestFile(t, "config.yaml", "string-flag: from-yaml\n")
+	missing := filepath.Join(filepath.Dir(jsonPath), "missing.json")
+
+	setup := func(configPath *string, s *string, i *int) *Config {
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				ConfigFile(String(configPath, "config", "CONFIG", "")),
+				String(s, "string-flag", "", ""),
+				Int(i, "int-flag", "", ""),
+			},
+			Files: []*File{JSONFile(jsonPath)},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		return c
+	}
+
+	t.Run("from args", func(t *testing.T) {
+		var (
+			configPath string
+			s          string
+			i          int
+		)
+		c := setup(&configPath, &s, &i)
+
+		os.Clearenv()
+		err := c.Parse([]string{"-config", yamlPath})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+		if s != "from-yaml" {
+			t.Errorf("-string-flag: got %q, expected %q", s, "from-yaml")
+		}
+		if i != 12 {
+			t.Errorf("-int-flag: got %d, expected %d", i, 12)
+		}
+	})
+
+	t.Run("from env", func(t *testing.T) {
+		var (
+			configPath string
+			s          string
+			i          int
+		)
+		c := setup(&configPath, &s, &i)
+
+		os.Clearenv()
+		os.Setenv("CONFIG", yamlPath)
+		err := c.Parse([]string{})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+		if s != "from-yaml" {
+			t.Errorf("-string-flag: got %q, expected %q", s, "from-yaml")
+		}
+	})
+
+	t.Run("missing default", func(t *testing.T) {
+		var (
+			configPath = missing
+			s          string
+			i          int
+		)
+		c := setup(&configPath, &s, &i)
+
+		os.Clearenv()
+		err := c.Parse([]string{})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+		if s != "from-json" {
+			t.Errorf("-string-flag: got %q, expected %q", s, "from-json")
+		}
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		var (
+			configPath string
+			s          string
+			i          int
+		)
+		c := setup(&configPath, &s, &i)
+
+		os.Clearenv()
+		err := c.Parse([]string{"-config", missing})
+		if err == nil {
+			t.Errorf("Config.Parse(...): expected error, got nil")
+		}
+	})
+
+	t.Run("repeatable", func(t *testing.T) {
+		var (
+			configPaths []string
+			s           string
+		)
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				ConfigFile(Repeatable(&configPaths, StringGenerator(), "config", "", "")),
+				String(&s, "string-flag", "", ""),
+			},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		err := c.Parse([]string{"-config", jsonPath, "-config", yamlPath})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+		if s != "from-json" {
+			t.Errorf("-string-flag: got %q, expected %q", s, "from-json")
+		}
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		configPath := "config.yaml"
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				ConfigFile(String(&configPath, "config", "CONFIG", "path to the configuration")),
+			},
+		}
+		buf := &bytes.Buffer{}
+		c.FlagSet.SetOutput(buf)
+
+		c.Usage()
+		for _, expected := range []string{"-config", "config.yaml", "(configuration file)"} {
+			if !strings.Contains(buf.String(), expected) {
+				t.Errorf("c.Usage() output: expected to find %q", expected)
+			}
+		}
+	})
+}
@@ -15,9 +15,36 @@ type Flag struct {
 	TypeHint   string
 	Required   bool
 	Positional bool
+	// Secret flags have their value masked in the usage, errors and Config.Dump (see Secret).
+	Secret bool
+	// Short is an optional one-letter alias of Name (see Short).
+	Short string
+	// Aliases are additional names of the flag on the command line (see Alias).
+	Aliases []string
+	// EnvAliases are environment variables consulted, in order, when Env isn't set (see EnvAlias).
+	EnvAliases []string
+	// Deprecated is a migration hint shown when a deprecated flag is used (see Deprecated).
+	Deprecated string
 
-	set          bool
-	defaultValue string
+	set           bool
+	origin        Origin
+	defaultValue  string
+	configFile    bool
+	rest          bool
+	minArgs       int
+	maxArgs       int
+	group         string
+	groupRequired bool
+	// deprecatedAliases and deprecatedEnvAliases hold the migration hints of the deprecated
+	// Aliases and EnvAliases, by index (see DeprecatedAlias).
+	deprecatedAliases    []string
+	deprecatedEnvAliases []string
+	// gnu is set in GNU mode (see Config.GNU), to spell the flag as `--name` in the errors and
+	// the groups of the usage.
+	gnu bool
+	// keyPath holds the names of the nested structs of a flag generated by StructToFlags,
+	// followed by its own name, to match the nested keys of the files (see File.Lookup).
+	keyPath []string
 }
 
 type isBoolFlagger interface {
@@ -25,13 +52,34 @@ type isBoolFlagger interface {
 }
 
 // Set proxies the .Set method on the underlying flag.Value. It is used to keep track
-// of wether a flag has been set or not.
+// of wether a flag has been set or not, and where its value came from.
 func (f *Flag) Set(v string) error {
+	return f.setFrom(v, Origin{
+		Kind: OriginFlag,
+		Name: f.Name,
+		Raw:  v,
+		gnu:  f.gnu,
+	})
+}
+
+func (f *Flag) setFrom(v string, origin Origin) error {
 	err := f.Value.Set(v)
+	if err != nil && f.Secret {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			validationErr.Value = maskSecret(validationErr.Value)
+		}
+		return maskedError{err: err}
+	}
 	if err != nil {
 		return err
 	}
 
+	// repeatable flags accumulate their values
+	if f.set && isSliceFlag(f) && f.origin.Kind == origin.Kind && f.origin.Name == origin.Name {
+		origin.Raw = f.origin.Raw + "," + origin.Raw
+	}
+	f.origin = origin
 	f.set = true
 	return nil
 }
@@ -49,10 +97,14 @@ func (f Flag) missingError() error {
 	switch {
 	default:
 		return errors.New("configuration variable doesn't have a flag or environment variable specified")
+	case f.rest:
+		return errors.New("missing arguments after --")
+	case f.Positional && (f.Name != "" || f.Env != ""):
+		return fmt.Errorf("missing positional argument %s", positionalName(&f))
 	case f.Name != "" && f.Env != "":
-		return fmt.Errorf("missing command line flag -%s or environment variable %s", f.Name, f.Env)
+		return fmt.Errorf("missing command line flag %s or environment variable %s", flagSpelling(f.Name, f.gnu), f.Env)
 	case f.Name != "":
-		return fmt.Errorf("missing command line flag -%s", f.Name)
+		return fmt.Errorf("missing command line flag %s", flagSpelling(f.Name, f.gnu))
 	case f.Env != "":
 		return fmt.Errorf("missing environment variable %s", f.Env)
 	}
@@ -62,3 +114,82 @@ func (f Flag) missingError() error {
 func (f Flag) IsSet() bool {
 	return f.set
 }
+
+// Origin returns where the flag's value came from. Flags that were not set report
+// an OriginDefault along with their default value.
+func (f Flag) Origin() Origin {
+	origin := f.origin
+	if !f.set {
+		origin = Origin{
+			Kind: OriginDefault,
+			Raw:  f.defaultValue,
+		}
+	}
+	if f.Secret {
+		origin.Raw = maskSecret(origin.Raw)
+	}
+
+	return origin
+}
+
+// setList sets the elements of a list on a repeatable flag one by one, instead of splitting them
+// like the values coming from the command line or the environment.
+func (f *Flag) setList(values []string) error {
+	vs, ok := unwrapValue(f.Value).(sliceValue)
+	if !ok {
+		return errors.New("expected a single value")
+	}
+
+	for _, v := range values {
+		err := vs.set(v)
+		if err != nil && f.Secret {
+			return maskedError{err: err}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	f.set = true
+
+	return nil
+}
+
+// flagArg is registered on the FlagSet in place of the flags, once per name. The FlagSet stops
+// at the first invalid value and includes it in its error, even for secret flags, so flagArg
+// keeps the error for Config to report along with the others.
+type flagArg struct {
+	*Flag
+	// name is the name the flagArg is registered with, recorded in the flag's Origin.
+	name string
+	raw  string
+	err  error
+	// list is set instead of the value given to Set when it isn't nil (see Config.setList).
+	list []string
+}
+
+func (a *flagArg) Set(s string) error {
+	var err error
+	if a.list != nil {
+		err = a.Flag.setList(a.list)
+	} else {
+		err = a.Flag.setFrom(s, Origin{
+			Kind: OriginFlag,
+			Name: a.name,
+			Raw:  s,
+			gnu:  a.Flag.gnu,
+		})
+	}
+	if err != nil && a.err == nil {
+		a.raw = s
+		a.err = err
+	}
+
+	return nil
+}
+
+func (a *flagArg) takeError() (raw string, err error) {
+	raw, err = a.raw, a.err
+	a.raw, a.err = "", nil
+
+	return raw, err
+}
@@ -26,6 +26,10 @@ func TestFlagMissingError(t *testing.T) {
 			flag:               Flag{Name: "foo", Env: "BAR"},
 			errorShouldContain: []string{"-foo", "BAR"},
 		},
+		{
+			flag:               Flag{Name: "foo", Positional: true},
+			errorShouldContain: []string{"positional argument foo"},
+		},
 	} {
 		err := test.flag.missingError()
 		if err == nil {
@@ -63,3 +67,31 @@ func TestFlagIsSet(t *testing.T) {
 		t.Errorf("Int(...).Set(%q).IsSet() = false, expected true", s)
 	}
 }
+
+func TestFlagOrigin(t *testing.T) {
+	var (
+		i  int
+		ss []string
+	)
+
+	f := Int(&i, "i", "I", "testing")
+	f.defaultValue = "0"
+	expected := Origin{Kind: OriginDefault, Raw: "0"}
+	if f.Origin() != expected {
+		t.Errorf("Int(...).Origin() = %+v, expected %+v", f.Origin(), expected)
+	}
+
+	_ = f.Set("42")
+	expected = Origin{Kind: OriginFlag, Name: "i", Raw: "42"}
+	if f.Origin() != expected {
+		t.Errorf("Int(...).Set(%q).Origin() = %+v, expected %+v", "42", f.Origin(), expected)
+	}
+
+	f = Repeatable(&ss, StringGenerator(), "ss", "SS", "testing")
+	_ = f.Set("foo")
+	_ = f.Set(`bar\,baz`)
+	expected = Origin{Kind: OriginFlag, Name: "ss", Raw: `foo,bar\,baz`}
+	if f.Origin() != expected {
+		t.Errorf("Repeatable(...).Set(...).Origin() = %+v, expected %+v", f.Origin(), expected)
+	}
+}
@@ -21,7 +21,7 @@ func (v float64Validators) Set(s string) error {
 	for _, validator := range v.validators {
 		err = validator(float64(*v.float64Value))
 		if err != nil {
-			return err
+			return &ValidationError{Value: s, Err: err}
 		}
 	}
 
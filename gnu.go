@@ -0,0 +1,176 @@
+package rig
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Short sets the one-letter alias of a flag, used as `-v` on the command line.
+// Noop if the flag has no Name.
+func Short(f *Flag, short string) *Flag {
+	if f.Name == "" {
+		return f
+	}
+
+	ret := *f
+	ret.Short = short
+	return &ret
+}
+
+// parseGNU parses the arguments following the GNU conventions: long flags are given as
+// `--name value` or `--name=value`, and short flags as `-n value` or `-nvalue`, possibly bundled
+// after boolean short flags (`-xvf file`). `--` ends the flags, as does the first positional
+// argument unless Config.Interspersed is set. The arguments following `--` are collected by the
+// Rest flag, if any.
+// The remaining arguments are handed to the FlagSet, so that they are available from Args.
+func (c *Config) parseGNU(arguments []string) error {
+	args := []string{}
+	for i := 0; i < len(arguments); i++ {
+		arg := arguments[i]
+		var (
+			consumed int
+			err      error
+		)
+		switch {
+		case arg == "--":
+			if c.restFlag() != nil {
+				c.setRest(arguments[i+1:])
+			} else {
+				args = append(args, arguments[i+1:]...)
+			}
+			i = len(arguments)
+			continue
+		case strings.HasPrefix(arg, "--"):
+			consumed, err = c.parseLongFlag(arg[2:], arguments[i+1:])
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			consumed, err = c.parseShortFlags(arg[1:], arguments[i+1:])
+		case c.Interspersed:
+			args = append(args, arg)
+			continue
+		default:
+			before, rest, ok := c.splitRest(arguments[i:])
+			if ok {
+				c.setRest(rest)
+			}
+			args = append(args, before...)
+			i = len(arguments)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		i += consumed
+	}
+
+	return c.parseQuietly(append([]string{"--"}, args...))
+}
+
+// parseLongFlag parses `name` or `name=value`, and returns the number of arguments from `next`
+// used as the value.
+func (c *Config) parseLongFlag(spec string, next []string) (int, error) {
+	name, value := spec, ""
+	hasValue := false
+	if i := strings.Index(spec, "="); i >= 0 {
+		name, value, hasValue = spec[:i], spec[i+1:], true
+	}
+
+	f := c.lookupLongFlag(name)
+	if f == nil {
+		return 0, c.unknownFlagError("--"+name, name == "help")
+	}
+
+	consumed := 0
+	if !hasValue {
+		if f.IsBoolFlag() {
+			value = "true"
+		} else {
+			if len(next) == 0 {
+				return 0, fmt.Errorf("flag needs an argument: --%s", name)
+			}
+			value, consumed = next[0], 1
+		}
+	}
+
+	return consumed, c.FlagSet.Set(name, value)
+}
+
+// parseShortFlags parses a group of short flags, the last one possibly followed by its value.
+// It returns the number of arguments from `next` used as the value.
+func (c *Config) parseShortFlags(spec string, next []string) (int, error) {
+	for i, r := range spec {
+		name := string(r)
+		f := c.lookupShortFlag(name)
+		if f == nil {
+			return 0, c.unknownFlagError("-"+name, name == "h")
+		}
+
+		if f.IsBoolFlag() {
+			err := c.FlagSet.Set(name, "true")
+			if err != nil {
+				return 0, err
+			}
+			continue
+		}
+
+		value := spec[i+utf8.RuneLen(r):]
+		if value != "" {
+			return 0, c.FlagSet.Set(name, value)
+		}
+		if len(next) == 0 {
+			return 0, fmt.Errorf("flag needs an argument: -%s", name)
+		}
+		return 1, c.FlagSet.Set(name, next[0])
+	}
+
+	return 0, nil
+}
+
+func (c *Config) lookupLongFlag(name string) *Flag {
+	for _, f := range c.Flags {
+		for _, n := range f.names() {
+			if n == name {
+				return f
+			}
+		}
+	}
+
+	return nil
+}
+
+// flagSpelling returns the name of a flag as expected on the command line: `--name` in GNU mode,
+// unless the name is a single letter, and `-name` otherwise.
+func (c *Config) flagSpelling(name string) string {
+	return flagSpelling(name, c.GNU)
+}
+
+func flagSpelling(name string, gnu bool) string {
+	if gnu && len(name) > 1 {
+		return "--" + name
+	}
+
+	return "-" + name
+}
+
+// lookupShortFlag looks for a flag by its Short alias or, failing that, by its one-letter Name.
+func (c *Config) lookupShortFlag(name string) *Flag {
+	for _, f := range c.Flags {
+		if f.Short != "" && f.Short == name {
+			return f
+		}
+	}
+
+	return c.lookupLongFlag(name)
+}
+
+func (c *Config) unknownFlagError(arg string, help bool) error {
+	if help {
+		return flag.ErrHelp
+	}
+
+	return &UnknownFlagError{
+		Arg:         arg,
+		Suggestions: c.suggestFlags(arg),
+	}
+}
@@ -0,0 +1,208 @@
+package rig
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigParseGNU(t *testing.T) {
+	type values struct {
+		Verbose bool
+		Extract bool
+		File    string
+		Name    string
+		N       int
+		Args    []string
+	}
+
+	newConfig := func(v *values) *Config {
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Short(Bool(&v.Verbose, "verbose", "", ""), "v"),
+				Short(Bool(&v.Extract, "extract", "", ""), "x"),
+				Short(String(&v.File, "file", "", ""), "f"),
+				String(&v.Name, "name", "", ""),
+				Int(&v.N, "n", "", ""),
+			},
+			GNU: true,
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		return c
+	}
+
+	for _, test := range []struct {
+		args     []string
+		expected values
+	}{
+		{
+			args:     []string{"--verbose", "--file=a.tar", "--name", "foo"},
+			expected: values{Verbose: true, File: "a.tar", Name: "foo", Args: []string{}},
+		},
+		{
+			args:     []string{"-xvf", "a.tar", "rest"},
+			expected: values{Verbose: true, Extract: true, File: "a.tar", Args: []string{"rest"}},
+		},
+		{
+			args:     []string{"-xfa.tar", "-n", "3", "--", "--verbose"},
+			expected: values{Extract: true, File: "a.tar", N: 3, Args: []string{"--verbose"}},
+		},
+		{
+			args:     []string{"--verbose=false", "-n3", "-", "-v"},
+			expected: values{N: 3, Args: []string{"-", "-v"}},
+		},
+	} {
+		var v values
+		c := newConfig(&v)
+
+		os.Clearenv()
+		err := c.Parse(test.args)
+		if err != nil {
+			t.Errorf("Config.Parse(%q): unexpected error: %s", test.args, err)
+			continue
+		}
+		v.Args = c.Args()
+		if !reflect.DeepEqual(v, test.expected) {
+			t.Errorf("Config.Parse(%q): got %+v, expected %+v", test.args, v, test.expected)
+		}
+	}
+
+	for _, test := range []struct {
+		args     []string
+		expected string
+	}{
+		{args: []string{"-verbose"}, expected: "flag provided but not defined: -e"},
+		{args: []string{"--nope"}, expected: "flag provided but not defined: --nope"},
+		{args: []string{"--verbos"}, expected: "flag provided but not defined: --verbos (did you mean --verbose?)"},
+		{args: []string{"--file"}, expected: "flag needs an argument: --file"},
+		{args: []string{"-vf"}, expected: "flag needs an argument: -f"},
+		{args: []string{"-n", "foo"}, expected: `invalid value "foo" for command line flag -n`},
+		{args: []string{"--help"}, expected: flag.ErrHelp.Error()},
+	} {
+		var v values
+		c := newConfig(&v)
+
+		os.Clearenv()
+		err := c.Parse(test.args)
+		if err == nil {
+			t.Errorf("Config.Parse(%q): expected error, got nil", test.args)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Config.Parse(%q): expected error %q to contain %q", test.args, err, test.expected)
+		}
+	}
+}
+
+func TestConfigParseShortAlias(t *testing.T) {
+	var verbose bool
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags: []*Flag{
+			Short(Bool(&verbose, "verbose", "", ""), "v"),
+		},
+	}
+	c.FlagSet.SetOutput(&bytes.Buffer{})
+
+	os.Clearenv()
+	err := c.Parse([]string{"-v"})
+	if err != nil {
+		t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+	}
+	if !verbose {
+		t.Errorf("Config.Parse(...): expected -v to set -verbose")
+	}
+}
+
+func TestConfigUsageGNU(t *testing.T) {
+	var (
+		verbose bool
+		file    string
+		n       int
+	)
+	for _, test := range []struct {
+		gnu      bool
+		expected []string
+	}{
+		{gnu: true, expected: []string{"-v, --verbose ", "-f, --file string", "-n int"}},
+		{gnu: false, expected: []string{"-v, -verbose ", "-f, -file string", "-n int"}},
+	} {
+		b := &bytes.Buffer{}
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Short(Bool(&verbose, "verbose", "", ""), "v"),
+				Short(String(&file, "file", "", ""), "f"),
+				Int(&n, "n", "", ""),
+			},
+			GNU: test.gnu,
+		}
+		c.FlagSet.SetOutput(b)
+		c.Usage()
+
+		for _, expected := range test.expected {
+			if !strings.Contains(b.String(), expected) {
+				t.Errorf("Config.Usage() (GNU: %t): expected %q to contain %q", test.gnu, b, expected)
+			}
+		}
+	}
+}
+
+func TestConfigParseGNUErrors(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{name: "invalid value", args: []string{"--pin=a"}, expected: `invalid value "a" for command line flag --pin`},
+		{name: "short invalid value", args: []string{"-p", "a"}, expected: `invalid value "a" for command line flag -p`},
+		{name: "group", args: []string{"--pin=1", "--alpha", "--beta"}, expected: "only one of --alpha or --beta can be set"},
+		{name: "missing", args: []string{}, expected: "missing command line flag --pin"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				pin         int
+				alpha, beta bool
+			)
+			alphaFlag := Bool(&alpha, "alpha", "", "")
+			betaFlag := Bool(&beta, "beta", "", "")
+			c := &Config{
+				FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+				Flags: []*Flag{
+					Required(Short(Int(&pin, "pin", "", ""), "p")),
+					alphaFlag,
+					betaFlag,
+				},
+				Groups: []*Group{Exclusive(alphaFlag, betaFlag)},
+				GNU:    true,
+			}
+			b := &bytes.Buffer{}
+			c.FlagSet.SetOutput(b)
+
+			os.Clearenv()
+			err := c.Parse(test.args)
+			if err == nil || !strings.Contains(err.Error(), test.expected) {
+				t.Errorf("Config.Parse(%q): expected error containing %q, got %v", test.args, test.expected, err)
+			}
+		})
+	}
+}
+
+func TestShort(t *testing.T) {
+	var s string
+	f := String(&s, "string", "", "")
+	short := Short(f, "s")
+	if short.Short != "s" || f.Short != "" {
+		t.Errorf("Short(...): got %q (original %q), expected %q (original unchanged)", short.Short, f.Short, "s")
+	}
+
+	f = String(&s, "", "STRING", "")
+	if Short(f, "s") != f {
+		t.Errorf("Short(...): expected flags without a name to be left unchanged")
+	}
+}
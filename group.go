@@ -0,0 +1,155 @@
+package rig
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Group is a set of mutually exclusive flags: at most one of them can be set, from any source.
+type Group struct {
+	// Flags must be the same *Flag values as the ones in Config.Flags.
+	Flags []*Flag
+	// Required groups need exactly one of their flags to be set.
+	Required bool
+}
+
+// Exclusive creates a Group of flags of which at most one can be set.
+func Exclusive(flags ...*Flag) *Group {
+	return &Group{
+		Flags: flags,
+	}
+}
+
+// ExactlyOne creates a Group of flags of which exactly one must be set.
+func ExactlyOne(flags ...*Flag) *Group {
+	return &Group{
+		Flags:    flags,
+		Required: true,
+	}
+}
+
+func (g *Group) check() error {
+	set := []string{}
+	for _, f := range g.Flags {
+		if f.set {
+			set = append(set, fmt.Sprintf("%s (%s)", flagDisplayName(f), f.Origin()))
+		}
+	}
+
+	switch {
+	case len(set) > 1:
+		return fmt.Errorf("only one of %s can be set, got %s", g.names("or"), joinWords(set, "and"))
+	case len(set) == 0 && g.Required:
+		return fmt.Errorf("one of %s is required", g.names("or"))
+	}
+
+	return nil
+}
+
+func (g *Group) names(conjunction string) string {
+	names := make([]string, 0, len(g.Flags))
+	for _, f := range g.Flags {
+		names = append(names, flagDisplayName(f))
+	}
+
+	return joinWords(names, conjunction)
+}
+
+// usage renders the group as `[-a | -b]`, or `(-a | -b)` for required groups.
+func (g *Group) usage() string {
+	names := make([]string, 0, len(g.Flags))
+	for _, f := range g.Flags {
+		names = append(names, flagDisplayName(f))
+	}
+	if g.Required {
+		return "(" + strings.Join(names, " | ") + ")"
+	}
+
+	return "[" + strings.Join(names, " | ") + "]"
+}
+
+// flagDisplayName is the name of a flag on the command line or, failing that, its environment
+// variable.
+func flagDisplayName(f *Flag) string {
+	if f.Name != "" {
+		return flagSpelling(f.Name, f.gnu)
+	}
+
+	return f.Env
+}
+
+func joinWords(words []string, conjunction string) string {
+	if len(words) < 2 {
+		return strings.Join(words, "")
+	}
+
+	return strings.Join(words[:len(words)-1], ", ") + " " + conjunction + " " + words[len(words)-1]
+}
+
+// groups returns the Groups applying to the flags provided: the ones from Config.Groups
+// covering only these flags, followed by the ones declared by the flags' "group" struct tags.
+func (c *Config) groups(flags []*Flag) []*Group {
+	in := map[*Flag]bool{}
+	for _, f := range flags {
+		in[f] = true
+	}
+
+	groups := []*Group{}
+	for _, g := range c.Groups {
+		covered := true
+		for _, f := range g.Flags {
+			covered = covered && in[f]
+		}
+		if covered {
+			groups = append(groups, g)
+		}
+	}
+
+	byName := map[string]*Group{}
+	for _, f := range flags {
+		if f.group == "" {
+			continue
+		}
+
+		g, ok := byName[f.group]
+		if !ok {
+			g = &Group{}
+			byName[f.group] = g
+			groups = append(groups, g)
+		}
+		g.Flags = append(g.Flags, f)
+		g.Required = g.Required || f.groupRequired
+	}
+
+	return groups
+}
+
+func (c *Config) checkGroups(flags []*Flag) error {
+	errs := &ParseError{}
+	errs.add(c.validateGroups())
+	for _, g := range c.groups(flags) {
+		errs.add(g.check())
+	}
+
+	return errs.errorOrNil()
+}
+
+// validateGroups rejects the Config.Groups referencing flags missing from Config.Flags, which
+// would never be set. This happens when a group is created before wrapping one of its flags
+// (with Required, for example), as the wrappers return copies.
+func (c *Config) validateGroups() error {
+	in := map[*Flag]bool{}
+	for _, f := range c.Flags {
+		in[f] = true
+	}
+
+	for _, g := range c.Groups {
+		for _, f := range g.Flags {
+			if !in[f] {
+				return fmt.Errorf("invalid group %s: %s is not one of Config.Flags", g.usage(), flagDisplayName(f))
+			}
+		}
+	}
+
+	return nil
+}
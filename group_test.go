@@ -0,0 +1,134 @@
+package rig
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConfigParseGroups(t *testing.T) {
+	for _, test := range []struct {
+		args          []string
+		env           map[string]string
+		required      bool
+		expectedError string
+	}{
+		{args: []string{"-token", "foo"}},
+		{args: []string{}},
+		{args: []string{"-token-file", "/run/token"}, required: true},
+		{
+			args:          []string{"-token-file", "/run/token"},
+			env:           map[string]string{"TOKEN": "foo"},
+			expectedError: `only one of -token or -token-file can be set, got -token (env variable "TOKEN") and -token-file (command line flag -token-file)`,
+		},
+		{
+			args:          []string{},
+			required:      true,
+			expectedError: "one of -token or -token-file is required",
+		},
+	} {
+		var token, tokenFile string
+		tokenFlag := String(&token, "token", "TOKEN", "")
+		tokenFileFlag := String(&tokenFile, "token-file", "TOKEN_FILE_PATH", "")
+		group := Exclusive(tokenFlag, tokenFileFlag)
+		if test.required {
+			group = ExactlyOne(tokenFlag, tokenFileFlag)
+		}
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags:   []*Flag{tokenFlag, tokenFileFlag},
+			Groups:  []*Group{group},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		for k, v := range test.env {
+			os.Setenv(k, v)
+		}
+		err := c.Parse(test.args)
+		if test.expectedError == "" {
+			if err != nil {
+				t.Errorf("Config.Parse(%q): unexpected error: %s", test.args, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != test.expectedError {
+			t.Errorf("Config.Parse(%q): expected error %q, got %v", test.args, test.expectedError, err)
+		}
+	}
+}
+
+func TestConfigParseGroupsUnknownFlag(t *testing.T) {
+	var x, y int
+	xFlag := Int(&x, "x", "", "")
+	yFlag := Int(&y, "y", "", "")
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags:   []*Flag{xFlag, Required(yFlag)},
+		Groups:  []*Group{Exclusive(xFlag, yFlag)},
+	}
+	c.FlagSet.SetOutput(&bytes.Buffer{})
+
+	os.Clearenv()
+	err := c.Parse([]string{"-x", "1", "-y", "2"})
+	expected := "invalid group [-x | -y]: -y is not one of Config.Flags"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Config.Parse(...): expected error %q, got %v", expected, err)
+	}
+}
+
+func TestConfigParseStructGroups(t *testing.T) {
+	type auth struct {
+		Token     string `group:"auth,require"`
+		TokenFile string `group:"auth"`
+	}
+	var v struct {
+		Primary   auth
+		Secondary auth
+		JSON      bool `group:"format"`
+		YAML      bool `group:"format"`
+	}
+	flags, err := StructToFlags(&v)
+	if err != nil {
+		t.Fatalf("StructToFlags(%T): unexpected error: %s", v, err)
+	}
+
+	b := &bytes.Buffer{}
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags:   flags,
+	}
+	c.FlagSet.SetOutput(b)
+
+	os.Clearenv()
+	err = c.Parse([]string{"-primary-token", "foo", "-secondary-token-file", "bar", "-json"})
+	if err != nil {
+		t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+	}
+
+	b.Reset()
+	c.Usage()
+	expected := "Usage of flagset [options] (-primary-token | -primary-token-file) (-secondary-token | -secondary-token-file) [-json | -yaml]:"
+	if !strings.Contains(b.String(), expected) {
+		t.Errorf("Config.Usage(): expected %q to contain %q", b, expected)
+	}
+}
+
+func TestJoinWords(t *testing.T) {
+	for _, test := range []struct {
+		words    []string
+		expected string
+	}{
+		{words: nil, expected: ""},
+		{words: []string{"a"}, expected: "a"},
+		{words: []string{"a", "b"}, expected: "a or b"},
+		{words: []string{"a", "b", "c"}, expected: "a, b or c"},
+	} {
+		got := joinWords(test.words, "or")
+		if got != test.expected {
+			t.Errorf("joinWords(%q) = %q, expected %q", test.words, got, test.expected)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package rig
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type defaulter interface {
+	SetDefaults()
+}
+
+type validator interface {
+	Validate() error
+}
+
+// setStructDefaults calls the SetDefaults methods of the nested structs, then the one of the
+// struct itself, so that it can override the defaults of its nested structs.
+func setStructDefaults(val reflect.Value) error {
+	fields, err := flagInfo(val)
+	if err != nil {
+		return err
+	}
+	for _, info := range fields {
+		if !info.isStruct {
+			continue
+		}
+
+		err = setStructDefaults(info.field.Elem())
+		if err != nil {
+			return err
+		}
+	}
+
+	if !val.CanAddr() {
+		return nil
+	}
+	if d, ok := val.Addr().Interface().(defaulter); ok {
+		d.SetDefaults()
+	}
+
+	return nil
+}
+
+// ValidateStruct calls the `Validate() error` method of the struct provided and of the nested
+// structs StructToFlags walks into, when they implement it. The errors are returned together,
+// as ValidationErrors prefixed with the path of the nested struct (".Bar: error").
+// ParseStruct and Command call ValidateStruct once the flags have been parsed.
+func ValidateStruct(v interface{}) error {
+	val := reflect.Indirect(reflect.ValueOf(v))
+	if val.Kind() != reflect.Struct {
+		return fmt.Errorf("%T is not a struct", v)
+	}
+
+	errs, err := validateStruct(val, "")
+	if err != nil {
+		return err
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+
+	return errs
+}
+
+func validateStruct(val reflect.Value, path string) (errorList, error) {
+	errs := errorList{}
+	if val.CanAddr() {
+		if v, ok := val.Addr().Interface().(validator); ok {
+			err := v.Validate()
+			if err != nil {
+				errs = append(errs, &ValidationError{Path: path, Err: err})
+			}
+		}
+	}
+
+	fields, err := flagInfo(val)
+	if err != nil {
+		return nil, err
+	}
+	for _, info := range fields {
+		if !info.isStruct {
+			continue
+		}
+
+		nested, err := validateStruct(info.field.Elem(), path+"."+info.typ.Name)
+		if err != nil {
+			return nil, err
+		}
+		errs = append(errs, nested...)
+	}
+
+	return errs, nil
+}
@@ -0,0 +1,124 @@
+package rig
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+)
+
+type hooksRange struct {
+	Min int
+	Max int
+}
+
+func (r *hooksRange) SetDefaults() {
+	r.Max = 10
+}
+
+func (r *hooksRange) Validate() error {
+	if r.Min > r.Max {
+		return errors.New("min must not exceed max")
+	}
+
+	return nil
+}
+
+type hooksConfig struct {
+	Name   string
+	Range  hooksRange
+	Nested struct {
+		Range hooksRange
+	}
+}
+
+func (c *hooksConfig) SetDefaults() {
+	c.Name = "default"
+	c.Nested.Range.Max = 20
+}
+
+func (c *hooksConfig) Validate() error {
+	if c.Name == "" {
+		return errors.New("name must not be empty")
+	}
+
+	return nil
+}
+
+func TestStructToFlagsSetDefaults(t *testing.T) {
+	var v hooksConfig
+	flags, err := StructToFlags(&v)
+	if err != nil {
+		t.Fatalf("StructToFlags(%T): unexpected error: %s", v, err)
+	}
+
+	if v.Name != "default" || v.Range.Max != 10 || v.Nested.Range.Max != 20 {
+		t.Errorf("StructToFlags(%T): got %+v, expected the defaults to be set, the parent's last", v, v)
+	}
+
+	b := &bytes.Buffer{}
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags:   flags,
+	}
+	c.FlagSet.SetOutput(b)
+	c.Usage()
+	if !strings.Contains(b.String(), `(default "default")`) {
+		t.Errorf("Config.Usage(): expected %q to show the default set by SetDefaults", b)
+	}
+}
+
+func TestValidateStruct(t *testing.T) {
+	var v hooksConfig
+	_, err := StructToFlags(&v)
+	if err != nil {
+		t.Fatalf("StructToFlags(%T): unexpected error: %s", v, err)
+	}
+
+	err = ValidateStruct(&v)
+	if err != nil {
+		t.Errorf("ValidateStruct(%T): unexpected error: %s", v, err)
+	}
+
+	v.Name = ""
+	v.Range.Min = 11
+	v.Nested.Range.Min = 21
+	err = ValidateStruct(&v)
+	if err == nil {
+		t.Fatalf("ValidateStruct(%T): expected error, got nil", v)
+	}
+	expected := "name must not be empty\n.Range: min must not exceed max\n.Nested.Range: min must not exceed max"
+	if err.Error() != expected {
+		t.Errorf("ValidateStruct(%T) = %q, expected %q", v, err, expected)
+	}
+
+	err = ValidateStruct(42)
+	if err == nil {
+		t.Errorf("ValidateStruct(42): expected error, got nil")
+	}
+}
+
+func TestCommandValidateStruct(t *testing.T) {
+	var v hooksConfig
+	ran := false
+	cmd := &Command{
+		FlagSet: flag.NewFlagSet("tool", flag.ContinueOnError),
+		Struct:  &v,
+		Run: func([]string) error {
+			ran = true
+			return nil
+		},
+	}
+	cmd.FlagSet.SetOutput(&bytes.Buffer{})
+
+	os.Clearenv()
+	err := cmd.Execute([]string{"-range-min", "12"})
+	if err == nil || !strings.Contains(err.Error(), ".Range: min must not exceed max") {
+		t.Errorf("Command.Execute(...): expected a validation error, got %v", err)
+	}
+	if ran {
+		t.Errorf("Command.Execute(...): expected Run not to be called")
+	}
+}
@@ -21,7 +21,7 @@ func (v intValidators) Set(s string) error {
 	for _, validator := range v.validators {
 		err = validator(int(*v.intValue))
 		if err != nil {
-			return err
+			return &ValidationError{Value: s, Err: err}
 		}
 	}
 
@@ -21,7 +21,7 @@ func (v int32Validators) Set(s string) error {
 	for _, validator := range v.validators {
 		err = validator(int32(*v.int32Value))
 		if err != nil {
-			return err
+			return &ValidationError{Value: s, Err: err}
 		}
 	}
 
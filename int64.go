@@ -21,7 +21,7 @@ func (v int64Validators) Set(s string) error {
 	for _, validator := range v.validators {
 		err = validator(int64(*v.int64Value))
 		if err != nil {
-			return err
+			return &ValidationError{Value: s, Err: err}
 		}
 	}
 
@@ -7,9 +7,11 @@ import (
 	"os"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
+	"unicode/utf8"
 )
 
 type fieldInfo struct {
@@ -22,22 +24,66 @@ type fieldInfo struct {
 	typeHint   string
 	required   bool
 	positional bool
+	rest       bool
+	minArgs    int
+	maxArgs    int
+	secret     bool
+	short      string
+	group      string
+	aliases    []string
+	envAliases []string
+	deprecated string
+	// deprecatedAliases are the flag and env aliases marked as deprecated.
+	deprecatedAliases []string
+
+	groupRequired bool
 
 	isStruct bool
 }
 
 func getFieldInfo(field reflect.Value, typ reflect.StructField) (*fieldInfo, error) {
-	flagName, required, positional, err := getFlagName(typ.Name, typ.Tag.Get("flag"))
+	flagName, required, positional, rest, err := getFlagName(typ.Name, typ.Tag.Get("flag"))
 	if err != nil {
 		return nil, err
 	}
 	if !field.CanInterface() || flagName == "-" {
 		return nil, nil
 	}
+	minArgs, maxArgs, err := getArity(typ.Tag.Get("flag"))
+	if err != nil {
+		return nil, err
+	}
+	if (minArgs != 0 || maxArgs != 0) && !positional {
+		return nil, fmt.Errorf("the %q and %q flag options require the %q option", minOpt, maxOpt, positionalOpt)
+	}
 	envName, err := getEnvName(typ.Name, typ.Tag.Get("env"))
 	if err != nil {
 		return nil, err
 	}
+	flagName, aliases, deprecatedAliases, err := splitAliases(flagName)
+	if err != nil {
+		return nil, err
+	}
+	envName, envAliases, deprecatedEnvAliases, err := splitAliases(envName)
+	if err != nil {
+		return nil, err
+	}
+	deprecatedAliases = append(deprecatedAliases, deprecatedEnvAliases...)
+	if len(deprecatedAliases) != 0 && typ.Tag.Get("deprecated") == "" {
+		return nil, fmt.Errorf("deprecated aliases %q require a \"deprecated\" tag", deprecatedAliases)
+	}
+	secret, err := getSecret(typ.Tag.Get("secret"))
+	if err != nil {
+		return nil, err
+	}
+	short, err := getShort(typ.Tag.Get("short"))
+	if err != nil {
+		return nil, err
+	}
+	group, groupRequired, err := getGroup(typ.Tag.Get("group"))
+	if err != nil {
+		return nil, err
+	}
 
 	info := &fieldInfo{
 		field: field,
@@ -49,6 +95,19 @@ func getFieldInfo(field reflect.Value, typ reflect.StructField) (*fieldInfo, err
 		typeHint:   typ.Tag.Get("typehint"),
 		required:   required,
 		positional: positional,
+		rest:       rest,
+		minArgs:    minArgs,
+		maxArgs:    maxArgs,
+		secret:     secret,
+		short:      short,
+		group:      group,
+		aliases:    aliases,
+		envAliases: envAliases,
+		deprecated: typ.Tag.Get("deprecated"),
+
+		deprecatedAliases: deprecatedAliases,
+
+		groupRequired: groupRequired,
 
 		isStruct: field.Kind() == reflect.Struct && !isFlagValue(field),
 	}
@@ -72,9 +131,12 @@ const (
 	inlineOpt     = "inline"
 	requireOpt    = "require"
 	positionalOpt = "positional"
+	restOpt       = "rest"
+	minOpt        = "min"
+	maxOpt        = "max"
 )
 
-func getFlagName(fieldName, tag string) (flagName string, required, positional bool, err error) {
+func getFlagName(fieldName, tag string) (flagName string, required, positional, rest bool, err error) {
 	inline := false
 	tt := strings.Split(tag, ",")
 	if len(tt) > 0 {
@@ -96,15 +158,46 @@ func getFlagName(fieldName, tag string) (flagName string, required, positional b
 			positional = true
 			continue
 		}
+		if t == restOpt {
+			rest = true
+			continue
+		}
+		if strings.HasPrefix(t, minOpt+"=") || strings.HasPrefix(t, maxOpt+"=") { // see getArity
+			continue
+		}
 
-		return flagName, required, positional, fmt.Errorf("unknown flag option %q", t)
+		return flagName, required, positional, rest, fmt.Errorf("unknown flag option %q", t)
 	}
 
 	if !inline && flagName == "" {
 		flagName = toSnakeCase(fieldName, "-")
 	}
 
-	return flagName, required, positional, nil
+	return flagName, required, positional, rest, nil
+}
+
+// getArity reads the "min" and "max" options of the flag tag.
+func getArity(tag string) (min, max int, err error) {
+	tt := strings.Split(tag, ",")
+	for _, t := range tt[1:] {
+		i := strings.Index(t, "=")
+		if i < 0 {
+			continue
+		}
+
+		n, err := strconv.Atoi(t[i+1:])
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("invalid flag option %q: expected a positive integer", t)
+		}
+		switch t[:i] {
+		case minOpt:
+			min = n
+		case maxOpt:
+			max = n
+		}
+	}
+
+	return min, max, nil
 }
 
 func getEnvName(fieldName, tag string) (envName string, err error) {
@@ -134,6 +227,67 @@ func getEnvName(fieldName, tag string) (envName string, err error) {
 	return envName, nil
 }
 
+// splitAliases splits the names given as "name|alias|...". The aliases suffixed with "!" are
+// returned as deprecated as well.
+func splitAliases(names string) (name string, aliases, deprecated []string, err error) {
+	nn := strings.Split(names, "|")
+	if strings.HasSuffix(nn[0], "!") {
+		return "", nil, nil, fmt.Errorf("invalid name %q: only the aliases can be deprecated", names)
+	}
+	for i, n := range nn[1:] {
+		if strings.HasSuffix(n, "!") {
+			n = strings.TrimSuffix(n, "!")
+			nn[i+1] = n
+			deprecated = append(deprecated, n)
+		}
+		if n == "" {
+			return "", nil, nil, fmt.Errorf("invalid name %q: empty alias", names)
+		}
+	}
+	if nn[0] == "" && len(nn) > 1 {
+		return "", nil, nil, fmt.Errorf("invalid name %q: empty alias", names)
+	}
+
+	return nn[0], nn[1:], deprecated, nil
+}
+
+func getSecret(tag string) (bool, error) {
+	if tag == "" {
+		return false, nil
+	}
+
+	secret, err := strconv.ParseBool(tag)
+	if err != nil {
+		return false, fmt.Errorf("invalid secret option %q", tag)
+	}
+
+	return secret, nil
+}
+
+func getShort(tag string) (string, error) {
+	if utf8.RuneCountInString(tag) > 1 {
+		return "", fmt.Errorf("invalid short option %q: expected a single character", tag)
+	}
+
+	return tag, nil
+}
+
+func getGroup(tag string) (group string, required bool, err error) {
+	tt := strings.Split(tag, ",")
+	group = tt[0]
+	for _, t := range tt[1:] {
+		if t != requireOpt {
+			return "", false, fmt.Errorf("unknown group option %q", t)
+		}
+		required = true
+	}
+	if group == "" && required {
+		return "", false, fmt.Errorf("missing group name in %q", tag)
+	}
+
+	return group, required, nil
+}
+
 func toSnakeCase(s, sep string) string {
 	ret := ""
 	prev := '\000'
@@ -173,7 +327,18 @@ func toUpperSnakeCase(s, sep string) string {
 // ParseStruct uses a default Config to parse the flages provided using os.Args.
 // StructtoFlags is used to generate the flags. the additionalFlags are applied after
 // the flags derived from the provided struct.
+// The struct is then validated using ValidateStruct.
 func ParseStruct(v interface{}, additionalFlags ...*Flag) error {
+	return parseStruct(v, nil, additionalFlags)
+}
+
+// ParseStructFiles behaves like ParseStruct, additionally consulting the configuration files
+// provided (see Config.Files) for the flags not set from the command line or the environment.
+func ParseStructFiles(v interface{}, files []*File, additionalFlags ...*Flag) error {
+	return parseStruct(v, files, additionalFlags)
+}
+
+func parseStruct(v interface{}, files []*File, additionalFlags []*Flag) error {
 	flags, err := StructToFlags(v)
 	if err != nil {
 		return err
@@ -184,39 +349,157 @@ func ParseStruct(v interface{}, additionalFlags ...*Flag) error {
 	config := &Config{
 		FlagSet: DefaultFlagSet(),
 		Flags:   flags,
+		Files:   files,
 	}
 
-	return config.Parse(os.Args[1:])
+	err = config.Parse(os.Args[1:])
+	if err != nil {
+		return err
+	}
+
+	err = ValidateStruct(v)
+	if err != nil {
+		return config.handleError(err)
+	}
+
+	return nil
 }
 
 // StructToFlags generates a set of Flag based on the provided struct.
 //
-// StructToFlags recognizes four struct flags: "flag", "env", "typehint" and "usage".
+// StructToFlags recognizes eight struct flags: "flag", "env", "typehint", "usage", "secret",
+// "short", "group" and "deprecated".
 // The flag and env names are inferred based on the field name unless values are provided in
 // the struct tags.
 // The field names are transformed from CamelCase to snake_case (using "-" as a separator for the flag).
 //
 // Additional options "inline" and "require" can be specified in the struct tags ("require" should be specified on the "flag" tag).
+// The "positional" and "rest" options of the "flag" tag create Positional and Rest flags; "rest"
+// requires a []string field. The number of values of a repeatable positional flag can be
+// constrained with the "min" and "max" options, as in `flag:"files,positional,min=1,max=3"`
+// (see Arity). StructToFlags rejects the layouts of positional flags that cannot be parsed
+// unambiguously.
 //
-// A flag or env can be marked as ignored by using `flag:"-"` and `env:"-"` respectively
+// A flag or env can be marked as ignored by using `flag:"-"` and `env:"-"` respectively.
+//
+// Fields marked with `secret:"true"` have their value masked (see Secret).
+// The "short" tag sets the one-letter alias of the flag (see Short), as in `short:"v"`.
+// Aliases can be given to the flag and env names, separated by "|", as in `flag:"listen|addr"`
+// and `env:"DB_URL|DATABASE_URL"` (see Alias and EnvAlias).
+// The "deprecated" tag marks the flag as deprecated with a migration hint, as in
+// `deprecated:"use -listen instead"` (see Deprecated). When some aliases are suffixed with "!",
+// as in `flag:"listen|addr!"`, only these aliases are deprecated (see DeprecatedAlias).
+// Fields sharing the same "group" tag are mutually exclusive (see Config.Groups). Exactly one of
+// them must be set when the "require" option is given on any of them, as in
+// `group:"auth,require"`. The groups of a nested struct are prefixed by its flag name.
+//
+// When the struct, or any nested struct, has a `SetDefaults()` method, it is called before the
+// flags are generated, so that the default values are shown by Config.Usage. The nested structs'
+// methods are called first. See ValidateStruct for the `Validate() error` method.
+//
+// The names can be customized using StructOptions.StructToFlags.
 func StructToFlags(v interface{}) ([]*Flag, error) {
+	return StructOptions{}.StructToFlags(v)
+}
+
+// StructOptions customizes the names of the flags generated from a struct.
+type StructOptions struct {
+	// EnvPrefix is prepended to every environment variable, including the ones given by the
+	// "env" tags, as in "MYAPP_".
+	EnvPrefix string
+	// FlagSeparator joins the flag name of a nested struct to the names of its flags, as in
+	// "db-host". Defaults to "-". The nested keys of the Files match the flags whatever the
+	// separator.
+	FlagSeparator string
+	// EnvSeparator joins the environment variable of a nested struct to the ones of its flags,
+	// as in "DB_HOST". Defaults to "_".
+	EnvSeparator string
+	// FlagNaming derives the flag names from the field names, unless a name is given by the
+	// "flag" tag. Defaults to KebabCase.
+	FlagNaming NamingStrategy
+}
+
+// A NamingStrategy derives a flag name from a field name.
+type NamingStrategy func(fieldName string) string
+
+// The naming strategies available for StructOptions.FlagNaming. A field named "DBHost" gives
+// "db-host", "db_host", "dbHost" and "db.host" respectively.
+var (
+	KebabCase NamingStrategy = func(s string) string {
+		return toSnakeCase(s, "-")
+	}
+	SnakeCase NamingStrategy = func(s string) string {
+		return toSnakeCase(s, "_")
+	}
+	CamelCase NamingStrategy = func(s string) string {
+		words := strings.Split(toSnakeCase(s, "_"), "_")
+		for i := 1; i < len(words); i++ {
+			r, size := utf8.DecodeRuneInString(words[i])
+			words[i] = string(unicode.ToUpper(r)) + words[i][size:]
+		}
+		return strings.Join(words, "")
+	}
+	DottedCase NamingStrategy = func(s string) string {
+		return toSnakeCase(s, ".")
+	}
+)
+
+// StructToFlags behaves like the StructToFlags function, naming the flags according to the
+// options.
+func (o StructOptions) StructToFlags(v interface{}) ([]*Flag, error) {
 	val := reflect.Indirect(reflect.ValueOf(v))
 	if val.Kind() != reflect.Struct {
 		return nil, fmt.Errorf("%T is not a struct", v)
 	}
 
+	err := setStructDefaults(val)
+	if err != nil {
+		return nil, err
+	}
+
+	flags, err := o.structToFlags(val)
+	if err != nil {
+		return nil, err
+	}
+	if o.EnvPrefix != "" {
+		for _, f := range flags {
+			if f.Env != "" {
+				f.Env = o.EnvPrefix + f.Env
+				f.EnvAliases = prefixNames(f.EnvAliases, o.EnvPrefix)
+			}
+		}
+	}
+
+	return flags, nil
+}
+
+func (o StructOptions) structToFlags(val reflect.Value) ([]*Flag, error) {
 	fields, err := flagInfo(val)
 	if err != nil {
 		return nil, err
 	}
 	flags := make([]*Flag, 0, len(fields))
 	for _, info := range fields {
+		info.flag = o.flagName(info)
 		if info.isStruct {
-			ff, err := StructToFlags(info.field.Interface())
+			ff, err := o.structToFlags(info.field.Elem())
 			if err != nil {
 				return nil, err
 			}
-			flags = append(flags, prefix(ff, info.flag, info.env, info.required)...)
+			ff = o.prefix(ff, info.flag, info.env, info.required)
+			for i, f := range ff {
+				ff[i] = applySecret(f, info.secret)
+			}
+			flags = append(flags, ff...)
+			continue
+		}
+
+		if info.rest {
+			v, ok := info.field.Interface().(*[]string)
+			if !ok {
+				return nil, fmt.Errorf(".%s: the rest option requires a []string field", info.typ.Name)
+			}
+			flags = append(flags, applyRequired(Rest(v), info.required))
 			continue
 		}
 
@@ -226,10 +509,30 @@ func StructToFlags(v interface{}) ([]*Flag, error) {
 		}
 		f = applyTypeHint(f, info.typeHint)
 		f = applyRequired(f, info.required)
+		f = applySecret(f, info.secret)
+		f = applyShort(f, info.short)
+		f = applyAliases(f, info.aliases, info.envAliases)
+		f = applyDeprecated(f, info.deprecated, info.deprecatedAliases)
 		f.Positional = info.positional
+		f.group = info.group
+		f.groupRequired = info.groupRequired
+		if info.minArgs != 0 || info.maxArgs != 0 {
+			f = Arity(f, info.minArgs, info.maxArgs)
+		}
 		flags = append(flags, f)
 	}
 
+	positionalFlags := []*Flag{}
+	for _, f := range flags {
+		if f.Positional {
+			positionalFlags = append(positionalFlags, f)
+		}
+	}
+	err = validatePositionals(positionalFlags)
+	if err != nil {
+		return nil, err
+	}
+
 	return flags, nil
 }
 
@@ -268,13 +571,83 @@ func applyRequired(f *Flag, required bool) *Flag {
 	return Required(f)
 }
 
-func prefix(ff []*Flag, flagName, env string, required bool) []*Flag {
+func applySecret(f *Flag, secret bool) *Flag {
+	if !secret {
+		return f
+	}
+
+	return Secret(f)
+}
+
+func applyAliases(f *Flag, aliases, envAliases []string) *Flag {
+	if len(aliases) != 0 {
+		f = Alias(f, aliases...)
+	}
+	if len(envAliases) != 0 {
+		f = EnvAlias(f, envAliases...)
+	}
+
+	return f
+}
+
+// applyDeprecated deprecates the aliases given or, if there are none, the flag itself.
+func applyDeprecated(f *Flag, message string, aliases []string) *Flag {
+	if message == "" {
+		return f
+	}
+	if len(aliases) == 0 {
+		return Deprecated(f, message)
+	}
+
+	for _, alias := range aliases {
+		f = DeprecatedAlias(f, alias, message)
+	}
+
+	return f
+}
+
+func applyShort(f *Flag, short string) *Flag {
+	if short == "" {
+		return f
+	}
+
+	return Short(f, short)
+}
+
+// flagName returns the flag name of the field, derived using FlagNaming unless given by the
+// "flag" tag.
+func (o StructOptions) flagName(info *fieldInfo) string {
+	if o.FlagNaming == nil || info.flag == "" || strings.Split(info.typ.Tag.Get("flag"), ",")[0] != "" {
+		return info.flag
+	}
+
+	return o.FlagNaming(info.typ.Name)
+}
+
+func (o StructOptions) prefix(ff []*Flag, flagName, env string, required bool) []*Flag {
+	flagSep, envSep := o.FlagSeparator, o.EnvSeparator
+	if flagSep == "" {
+		flagSep = "-"
+	}
+	if envSep == "" {
+		envSep = "_"
+	}
+
 	for i, f := range ff {
 		if flagName != "" && f.Name != "" {
-			f.Name = flagName + "-" + f.Name
+			if f.keyPath == nil {
+				f.keyPath = []string{f.Name}
+			}
+			f.keyPath = append([]string{flagName}, f.keyPath...)
+			f.Name = flagName + flagSep + f.Name
+			f.Aliases = prefixNames(f.Aliases, flagName+flagSep)
 		}
 		if env != "" && f.Env != "" {
-			f.Env = env + "_" + f.Env
+			f.Env = env + envSep + f.Env
+			f.EnvAliases = prefixNames(f.EnvAliases, env+envSep)
+		}
+		if flagName != "" && f.group != "" {
+			f.group = flagName + flagSep + f.group
 		}
 		ff[i] = applyRequired(f, required)
 	}
@@ -282,6 +655,19 @@ func prefix(ff []*Flag, flagName, env string, required bool) []*Flag {
 	return ff
 }
 
+func prefixNames(names []string, prefix string) []string {
+	if len(names) == 0 {
+		return names
+	}
+
+	ret := make([]string, 0, len(names))
+	for _, name := range names {
+		ret = append(ret, prefix+name)
+	}
+
+	return ret
+}
+
 func getCompatiblePointerToPointerElem(i interface{}) (reflect.Value, bool) {
 	switch i.(type) {
 	case **url.URL, **regexp.Regexp:
@@ -137,7 +137,7 @@ func TestPrefix(t *testing.T) {
 
 		flagPrefix := "prefix"
 		envPrefix := "PREFIX"
-		flags = prefix(flags, flagPrefix, envPrefix, false)
+		flags = StructOptions{}.prefix(flags, flagPrefix, envPrefix, false)
 		for _, f := range flags {
 			if f.Name != "" && !strings.HasPrefix(f.Name, "prefix-") {
 				t.Errorf("prefix(flags, %q, %q, false): expected flag name %q to have '%s-' prefix", flagPrefix, envPrefix, f.Name, flagPrefix)
@@ -156,7 +156,7 @@ func TestPrefix(t *testing.T) {
 
 		flagPrefix := "prefix"
 		envPrefix := "PREFIX"
-		flags = prefix(flags, flagPrefix, envPrefix, true)
+		flags = StructOptions{}.prefix(flags, flagPrefix, envPrefix, true)
 		for _, f := range flags {
 			if f.Name != "" && !strings.HasPrefix(f.Name, "prefix-") {
 				t.Errorf("prefix(flags, %q, %q, true): expected flag name %q to have '%s-' prefix", flagPrefix, envPrefix, f.Name, flagPrefix)
@@ -615,6 +615,58 @@ func TestGetFieldInfo(t *testing.T) {
 		}
 	})
 
+	t.Run("secret", func(t *testing.T) {
+		type secretField struct {
+			FlagA string `secret:"true"`
+			FlagB string `secret:"foo"`
+		}
+		v := &secretField{}
+		val := reflect.Indirect(reflect.ValueOf(v))
+		typ := val.Type()
+
+		fieldTyp, _ := typ.FieldByName("FlagA")
+		fi, err := getFieldInfo(val.FieldByName("FlagA"), fieldTyp)
+		if err != nil {
+			t.Errorf("getFieldInfo(%T): unexpected error: %v", v, err)
+			return
+		}
+		if !fi.secret {
+			t.Errorf("getFieldInfo(%T).secret = false, expected true", v)
+		}
+
+		fieldTyp, _ = typ.FieldByName("FlagB")
+		_, err = getFieldInfo(val.FieldByName("FlagB"), fieldTyp)
+		if err == nil {
+			t.Errorf("getFieldInfo(%T): expected error, got nil", v)
+		}
+	})
+
+	t.Run("short", func(t *testing.T) {
+		type shortField struct {
+			FlagA bool `short:"v"`
+			FlagB bool `short:"vv"`
+		}
+		v := &shortField{}
+		val := reflect.Indirect(reflect.ValueOf(v))
+		typ := val.Type()
+
+		fieldTyp, _ := typ.FieldByName("FlagA")
+		fi, err := getFieldInfo(val.FieldByName("FlagA"), fieldTyp)
+		if err != nil {
+			t.Errorf("getFieldInfo(%T): unexpected error: %v", v, err)
+			return
+		}
+		if fi.short != "v" {
+			t.Errorf("getFieldInfo(%T).short = %q, expected %q", v, fi.short, "v")
+		}
+
+		fieldTyp, _ = typ.FieldByName("FlagB")
+		_, err = getFieldInfo(val.FieldByName("FlagB"), fieldTyp)
+		if err == nil {
+			t.Errorf("getFieldInfo(%T): expected error, got nil", v)
+		}
+	})
+
 	t.Run("non-addressable field", func(t *testing.T) {
 		type nonAddressableField struct {
 			FlagA int `flag:"flag-a,require"`
@@ -641,6 +693,7 @@ func TestGetFlagName(t *testing.T) {
 		FlagName   string
 		Required   bool
 		Positional bool
+		Rest       bool
 		Error      bool
 	}{
 		{Field: "", Tag: "", FlagName: "", Required: false, Error: false},
@@ -648,6 +701,8 @@ func TestGetFlagName(t *testing.T) {
 		{Field: "FooBar", Tag: "bar-baz", FlagName: "bar-baz", Required: false, Error: false},
 		{Field: "FooBar", Tag: "bar-baz,require", FlagName: "bar-baz", Required: true, Error: false},
 		{Field: "FooBar", Tag: "bar-baz,require,positional", FlagName: "bar-baz", Required: true, Positional: true, Error: false},
+		{Field: "FooBar", Tag: ",rest,require", FlagName: "foo-bar", Required: true, Rest: true, Error: false},
+		{Field: "FooBar", Tag: ",positional,min=1,max=3", FlagName: "foo-bar", Positional: true, Error: false},
 		{Field: "FooBar", Tag: "bar-baz,inline", FlagName: "", Required: false, Error: false},
 		{Field: "FooBar", Tag: "bar-baz,inline,require", FlagName: "", Required: true, Error: false},
 		{Field: "FooBar", Tag: ",inline,require", FlagName: "", Required: true, Error: false},
@@ -655,7 +710,7 @@ func TestGetFlagName(t *testing.T) {
 		{Field: "FooBar", Tag: ",invalidoption", FlagName: "", Required: false, Error: true},
 		{Field: "FooBar", Tag: ",", FlagName: "", Required: false, Error: true},
 	} {
-		got, required, positional, err := getFlagName(test.Field, test.Tag)
+		got, required, positional, rest, err := getFlagName(test.Field, test.Tag)
 		if test.Error && err == nil {
 			t.Errorf("getFlagName(%q, %q): expected error, got nil", test.Field, test.Tag)
 			continue
@@ -676,6 +731,100 @@ func TestGetFlagName(t *testing.T) {
 		if positional != test.Positional {
 			t.Errorf("getFlagName(%q, %q) positional = %v, expected %v", test.Field, test.Tag, positional, test.Positional)
 		}
+		if rest != test.Rest {
+			t.Errorf("getFlagName(%q, %q) rest = %v, expected %v", test.Field, test.Tag, rest, test.Rest)
+		}
+	}
+}
+
+func TestGetArity(t *testing.T) {
+	for _, test := range []struct {
+		tag      string
+		min, max int
+		err      bool
+	}{
+		{tag: "", min: 0, max: 0},
+		{tag: "files,positional", min: 0, max: 0},
+		{tag: "files,positional,min=1", min: 1, max: 0},
+		{tag: ",positional,min=2,max=5", min: 2, max: 5},
+		{tag: ",positional,min=foo", err: true},
+		{tag: ",positional,max=-1", err: true},
+	} {
+		min, max, err := getArity(test.tag)
+		if test.err {
+			if err == nil {
+				t.Errorf("getArity(%q): expected error, got nil", test.tag)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("getArity(%q): unexpected error: %v", test.tag, err)
+			continue
+		}
+		if min != test.min || max != test.max {
+			t.Errorf("getArity(%q) = %d, %d, expected %d, %d", test.tag, min, max, test.min, test.max)
+		}
+	}
+}
+
+func TestStructToFlagsPositionalLayout(t *testing.T) {
+	var valid struct {
+		Out   string   `flag:",positional,require"`
+		Files []string `flag:",positional,min=1,max=3"`
+	}
+	flags, err := StructToFlags(&valid)
+	if err != nil {
+		t.Fatalf("StructToFlags(%T): unexpected error: %s", valid, err)
+	}
+	if f := flags[1]; f.minArgs != 1 || f.maxArgs != 3 {
+		t.Errorf("StructToFlags(%T): got arity %d-%d, expected 1-3", valid, f.minArgs, f.maxArgs)
+	}
+
+	var optionalFirst struct {
+		Out   string `flag:",positional"`
+		Input string `flag:",positional,require"`
+	}
+	_, err = StructToFlags(&optionalFirst)
+	if err == nil {
+		t.Errorf("StructToFlags(%T): expected error, got nil", optionalFirst)
+	}
+
+	var notPositional struct {
+		Files []string `flag:",min=1"`
+	}
+	_, err = StructToFlags(&notPositional)
+	if err == nil {
+		t.Errorf("StructToFlags(%T): expected error, got nil", notPositional)
+	}
+}
+
+func TestGetGroup(t *testing.T) {
+	for _, test := range []struct {
+		tag      string
+		group    string
+		required bool
+		err      bool
+	}{
+		{tag: "", group: ""},
+		{tag: "auth", group: "auth"},
+		{tag: "auth,require", group: "auth", required: true},
+		{tag: ",require", err: true},
+		{tag: "auth,foo", err: true},
+	} {
+		group, required, err := getGroup(test.tag)
+		if test.err {
+			if err == nil {
+				t.Errorf("getGroup(%q): expected error, got nil", test.tag)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("getGroup(%q): unexpected error: %v", test.tag, err)
+			continue
+		}
+		if group != test.group || required != test.required {
+			t.Errorf("getGroup(%q) = %q, %t, expected %q, %t", test.tag, group, required, test.group, test.required)
+		}
 	}
 }
 
@@ -838,6 +987,161 @@ func TestStructToFlags(t *testing.T) {
 			t.Errorf("StructToFlags(%T): unexpected error: %v", v, err)
 		}
 	})
+
+	t.Run("aliases", func(t *testing.T) {
+		type database struct {
+			URL string `flag:"url|address" env:"URL|ADDRESS"`
+		}
+		type aliases struct {
+			Listen string `flag:"listen|addr" env:"LISTEN"`
+			DB     database
+		}
+		v := &aliases{}
+
+		flags, err := StructOptions{EnvPrefix: "APP_"}.StructToFlags(v)
+		if err != nil {
+			t.Fatalf("StructToFlags(%T): unexpected error: %v", v, err)
+		}
+		if flags[0].Name != "listen" || !reflect.DeepEqual(flags[0].Aliases, []string{"addr"}) || flags[0].EnvAliases != nil {
+			t.Errorf("StructToFlags(%T)[0] = %q %q %q, expected %q %q and no env alias", v, flags[0].Name, flags[0].Aliases, flags[0].EnvAliases, "listen", []string{"addr"})
+		}
+		if !reflect.DeepEqual(flags[1].Aliases, []string{"db-address"}) || !reflect.DeepEqual(flags[1].EnvAliases, []string{"APP_DB_ADDRESS"}) {
+			t.Errorf("StructToFlags(%T)[1]: got aliases %q and %q, expected %q and %q", v, flags[1].Aliases, flags[1].EnvAliases, []string{"db-address"}, []string{"APP_DB_ADDRESS"})
+		}
+	})
+
+	t.Run("deprecated", func(t *testing.T) {
+		type deprecated struct {
+			Addr string `deprecated:"use -listen instead"`
+		}
+		v := &deprecated{}
+
+		flags, err := StructToFlags(v)
+		if err != nil {
+			t.Fatalf("StructToFlags(%T): unexpected error: %v", v, err)
+		}
+		if flags[0].Deprecated != "use -listen instead" {
+			t.Errorf("StructToFlags(%T)[0].Deprecated = %q, expected %q", v, flags[0].Deprecated, "use -listen instead")
+		}
+	})
+
+	t.Run("deprecated aliases", func(t *testing.T) {
+		type deprecatedAliases struct {
+			Listen string `flag:"listen|addr!" env:"LISTEN|ADDR!" deprecated:"use -listen instead"`
+		}
+		v := &deprecatedAliases{}
+
+		flags, err := StructOptions{EnvPrefix: "APP_"}.StructToFlags(v)
+		if err != nil {
+			t.Fatalf("StructToFlags(%T): unexpected error: %v", v, err)
+		}
+		if flags[0].Deprecated != "" || !reflect.DeepEqual(flags[0].Aliases, []string{"addr"}) || !reflect.DeepEqual(flags[0].EnvAliases, []string{"APP_ADDR"}) {
+			t.Errorf("StructToFlags(%T)[0]: got aliases %q and %q (deprecated %q), expected %q and %q", v, flags[0].Aliases, flags[0].EnvAliases, flags[0].Deprecated, []string{"addr"}, []string{"APP_ADDR"})
+		}
+		for _, origin := range []Origin{{Kind: OriginFlag, Name: "addr"}, {Kind: OriginEnv, Name: "APP_ADDR"}} {
+			if alias, _ := flags[0].deprecatedAlias(origin); alias != origin.Name {
+				t.Errorf("StructToFlags(%T)[0]: expected %s to be deprecated", v, origin)
+			}
+		}
+	})
+
+	t.Run("deprecated alias without message", func(t *testing.T) {
+		type deprecatedAlias struct {
+			Listen string `flag:"listen|addr!"`
+		}
+		v := &deprecatedAlias{}
+
+		_, err := StructToFlags(v)
+		if err == nil {
+			t.Errorf("StructToFlags(%T): expected error, got nil", v)
+		}
+	})
+
+	t.Run("empty alias", func(t *testing.T) {
+		type emptyAlias struct {
+			Listen string `flag:"listen|"`
+		}
+		v := &emptyAlias{}
+
+		_, err := StructToFlags(v)
+		if err == nil {
+			t.Errorf("StructToFlags(%T): expected error, got nil", v)
+		}
+	})
+}
+
+func TestNamingStrategies(t *testing.T) {
+	for _, test := range []struct {
+		Naming   NamingStrategy
+		In       string
+		Expected string
+	}{
+		{Naming: KebabCase, In: "DBHost", Expected: "db-host"},
+		{Naming: SnakeCase, In: "DBHost", Expected: "db_host"},
+		{Naming: CamelCase, In: "DBHost", Expected: "dbHost"},
+		{Naming: CamelCase, In: "Foo", Expected: "foo"},
+		{Naming: CamelCase, In: "fooBarBaz", Expected: "fooBarBaz"},
+		{Naming: DottedCase, In: "DBHost", Expected: "db.host"},
+	} {
+		got := test.Naming(test.In)
+		if got != test.Expected {
+			t.Errorf("NamingStrategy(%q) = %q, expected %q", test.In, got, test.Expected)
+		}
+	}
+}
+
+func TestStructOptionsStructToFlags(t *testing.T) {
+	type database struct {
+		Host     string
+		Port     int    `flag:"db-port" env:"PORT"`
+		Password string `flag:",require"`
+	}
+	type config struct {
+		DB      database
+		Verbose bool
+		Ignored string   `env:"-"`
+		Inline  database `flag:",inline" env:",inline"`
+	}
+
+	opts := StructOptions{
+		EnvPrefix:     "MYAPP_",
+		FlagSeparator: ".",
+		EnvSeparator:  "__",
+		FlagNaming:    CamelCase,
+	}
+	flags, err := opts.StructToFlags(&config{})
+	if err != nil {
+		t.Fatalf("StructOptions.StructToFlags(...): unexpected error: %v", err)
+	}
+
+	type name struct {
+		Flag, Env string
+	}
+	got := make([]name, 0, len(flags))
+	for _, f := range flags {
+		got = append(got, name{Flag: f.Name, Env: f.Env})
+	}
+	expected := []name{
+		{Flag: "db.host", Env: "MYAPP_DB__HOST"},
+		{Flag: "db.db-port", Env: "MYAPP_DB__PORT"},
+		{Flag: "db.password", Env: "MYAPP_DB__PASSWORD"},
+		{Flag: "verbose", Env: "MYAPP_VERBOSE"},
+		{Flag: "ignored", Env: ""},
+		{Flag: "host", Env: "MYAPP_HOST"},
+		{Flag: "db-port", Env: "MYAPP_PORT"},
+		{Flag: "password", Env: "MYAPP_PASSWORD"},
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("StructOptions.StructToFlags(...) = %+v, expected %+v", got, expected)
+	}
+
+	flags, err = StructToFlags(&config{})
+	if err != nil {
+		t.Fatalf("StructToFlags(...): unexpected error: %v", err)
+	}
+	if flags[0].Name != "db-host" || flags[0].Env != "DB_HOST" {
+		t.Errorf("StructToFlags(...): got %q and %q, expected the default names %q and %q", flags[0].Name, flags[0].Env, "db-host", "DB_HOST")
+	}
 }
 
 func TestParseStruct(t *testing.T) {
@@ -878,6 +1182,33 @@ func TestParseStruct(t *testing.T) {
 	})
 }
 
+func TestParseStructFiles(t *testing.T) {
+	path := writeTestFile(t, "config.yaml", "flag-a: 12\nflag-b: 13\n")
+
+	type fields struct {
+		FlagA int `env:"FLAG_A"`
+		FlagB int
+	}
+	v := fields{}
+
+	os.Clearenv()
+	os.Setenv("FLAG_A", "42")
+
+	commandLineFlags := commandLineFlags(t)
+	err := ParseStructFiles(&v, []*File{YAMLFile(path)}, commandLineFlags...)
+	if err != nil {
+		t.Errorf("ParseStructFiles(%T): unexpected error: %v", v, err)
+		return
+	}
+
+	if v.FlagA != 42 {
+		t.Errorf("ParseStructFiles(%T).FlagA = %d, expected %d", v, v.FlagA, 42)
+	}
+	if v.FlagB != 13 {
+		t.Errorf("ParseStructFiles(%T).FlagB = %d, expected %d", v, v.FlagB, 13)
+	}
+}
+
 func ExampleParseStruct() {
 	type Configuration struct {
 		URL      *url.URL `flag:",require" typehint:"website_url"`
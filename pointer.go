@@ -71,8 +71,22 @@ func Pointer(f *Flag, v interface{}) *Flag {
 		Usage:    f.Usage,
 		TypeHint: f.TypeHint,
 		Required: f.Required,
-
-		set:          f.set,
-		defaultValue: f.defaultValue,
+		Secret:   f.Secret,
+		Short:    f.Short,
+
+		Aliases:    f.Aliases,
+		EnvAliases: f.EnvAliases,
+		Deprecated: f.Deprecated,
+
+		set:           f.set,
+		origin:        f.origin,
+		defaultValue:  f.defaultValue,
+		configFile:    f.configFile,
+		group:         f.group,
+		groupRequired: f.groupRequired,
+		keyPath:       f.keyPath,
+
+		deprecatedAliases:    f.deprecatedAliases,
+		deprecatedEnvAliases: f.deprecatedEnvAliases,
 	}
 }
@@ -21,7 +21,7 @@ func (v regexpValidators) Set(s string) error {
 	for _, validator := range v.validators {
 		err = validator(*v.regexpValue.Regexp)
 		if err != nil {
-			return err
+			return &ValidationError{Value: s, Err: err}
 		}
 	}
 
@@ -79,6 +79,11 @@ func splitRepeatable(in string) []string {
 	return out
 }
 
+func isSliceFlag(f *Flag) bool {
+	_, ok := unwrapValue(f.Value).(sliceValue)
+	return ok
+}
+
 func (vs sliceValue) set(s string) error {
 	if vs.value.Kind() != reflect.Ptr {
 		return fmt.Errorf("expected pointer to slice, got %s instead", vs.value.Kind())
@@ -105,7 +110,7 @@ func (vs sliceValue) set(s string) error {
 	for _, validator := range vs.validators {
 		err = validator(vi)
 		if err != nil {
-			return err
+			return &ValidationError{Value: s, Err: err}
 		}
 	}
 
@@ -21,3 +21,28 @@ func Positional(f *Flag) *Flag {
 	ret.Positional = true
 	return &ret
 }
+
+// Arity sets the minimum and maximum number of values of a repeatable positional flag.
+// A max of 0 means there is no maximum. Config.Parse reports an error when the arity is set on
+// a flag that isn't repeatable.
+func Arity(f *Flag, min, max int) *Flag {
+	ret := *f
+	ret.minArgs = min
+	ret.maxArgs = max
+	return &ret
+}
+
+// ConfigFile marks a flag as selecting the configuration files loaded by Config.Parse.
+// The flag is resolved from the command line and the environment before any file is loaded,
+// and its value (or values, for repeatable flags) are used as paths, the format being picked
+// based on the extension (see NewFile).
+// When the flag is left to its default value, missing files are ignored.
+func ConfigFile(f *Flag) *Flag {
+	if f.configFile {
+		return f
+	}
+
+	ret := *f
+	ret.configFile = true
+	return &ret
+}
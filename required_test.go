@@ -71,3 +71,35 @@ func TestPositional(t *testing.T) {
 		t.Errorf("Positional(Var(...)).Positional = false, expected true")
 	}
 }
+
+func TestConfigFile(t *testing.T) {
+	var s string
+
+	f := String(&s, "config", "CONFIG", "testing ConfigFile on String")
+	r := ConfigFile(f)
+
+	if f.configFile {
+		t.Errorf("String(...).configFile = true, expected false")
+	}
+	if !r.configFile {
+		t.Errorf("ConfigFile(String(...)).configFile = false, expected true")
+	}
+
+	r = ConfigFile(ConfigFile(f))
+	if !r.configFile {
+		t.Errorf("ConfigFile(ConfigFile(String(...))).configFile = false, expected true")
+	}
+}
+
+func TestArity(t *testing.T) {
+	var ss []string
+
+	f := Positional(Repeatable(&ss, StringGenerator(), "files", "", ""))
+	r := Arity(f, 1, 3)
+	if f.minArgs != 0 || f.maxArgs != 0 {
+		t.Errorf("Repeatable(...) arity = %d-%d, expected 0-0", f.minArgs, f.maxArgs)
+	}
+	if r.minArgs != 1 || r.maxArgs != 3 {
+		t.Errorf("Arity(Repeatable(...), 1, 3) arity = %d-%d, expected 1-3", r.minArgs, r.maxArgs)
+	}
+}
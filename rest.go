@@ -0,0 +1,77 @@
+package rig
+
+import (
+	"strings"
+)
+
+// Rest creates a flag collecting the arguments following the first "--" on the command line,
+// untouched: they are neither split on commas nor assigned to the positional flags.
+// The flag has no name and is not consulted by the sources. It is shown as `-- args...` in the
+// usage, and can be marked as Required.
+func Rest(v *[]string) *Flag {
+	return &Flag{
+		Value: restValue{v: v},
+		rest:  true,
+	}
+}
+
+type restValue struct {
+	v *[]string
+}
+
+func (r restValue) String() string {
+	if r.v == nil {
+		return ""
+	}
+
+	return strings.Join(*r.v, " ")
+}
+
+func (r restValue) Set(s string) error {
+	*r.v = append(*r.v, s)
+	return nil
+}
+
+func (c *Config) restFlag() *Flag {
+	for _, f := range c.Flags {
+		if f.rest {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// setRest sets the Rest flag, if any, to the arguments provided.
+func (c *Config) setRest(args []string) {
+	f := c.restFlag()
+	if f == nil {
+		return
+	}
+
+	for _, arg := range args {
+		_ = f.Value.Set(arg)
+	}
+	f.origin = Origin{
+		Kind: OriginPositional,
+		Name: "--",
+		Raw:  strings.Join(args, " "),
+	}
+	f.set = true
+}
+
+// splitRest returns the arguments preceding and following the first "--" when the Config has
+// a Rest flag.
+func (c *Config) splitRest(args []string) (before, rest []string, ok bool) {
+	if c.restFlag() == nil {
+		return args, nil, false
+	}
+
+	for i, arg := range args {
+		if arg == "--" {
+			return args[:i], args[i+1:], true
+		}
+	}
+
+	return args, nil, false
+}
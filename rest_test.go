@@ -0,0 +1,144 @@
+package rig
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestConfigParseRest(t *testing.T) {
+	for _, test := range []struct {
+		args          []string
+		gnu           bool
+		interspersed  bool
+		expectedFiles []string
+		expectedRest  []string
+		verbose       bool
+	}{
+		{
+			args:         []string{"-verbose", "--", "a,b", "-x", "--"},
+			expectedRest: []string{"a,b", "-x", "--"},
+			verbose:      true,
+		},
+		{
+			args:          []string{"in.txt", "-verbose", "--", "-x"},
+			expectedFiles: []string{"in.txt", "-verbose"},
+			expectedRest:  []string{"-x"},
+		},
+		{
+			args:          []string{"in.txt", "-verbose", "--", "-x"},
+			interspersed:  true,
+			expectedFiles: []string{"in.txt"},
+			expectedRest:  []string{"-x"},
+			verbose:       true,
+		},
+		{
+			args:          []string{"-v", "in.txt", "--", "--verbose"},
+			gnu:           true,
+			expectedFiles: []string{"in.txt"},
+			expectedRest:  []string{"--verbose"},
+			verbose:       true,
+		},
+		{
+			args:          []string{"in.txt", "--"},
+			gnu:           true,
+			expectedFiles: []string{"in.txt"},
+			expectedRest:  []string{},
+		},
+	} {
+		var (
+			verbose bool
+			files   []string
+			rest    []string
+		)
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Short(Bool(&verbose, "verbose", "", ""), "v"),
+				Positional(Repeatable(&files, StringGenerator(), "files", "", "")),
+				Rest(&rest),
+			},
+			GNU:          test.gnu,
+			Interspersed: test.interspersed,
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		err := c.Parse(test.args)
+		if err != nil {
+			t.Errorf("Config.Parse(%q): unexpected error: %s", test.args, err)
+			continue
+		}
+		if verbose != test.verbose {
+			t.Errorf("Config.Parse(%q): got verbose=%t, expected %t", test.args, verbose, test.verbose)
+		}
+		if !reflect.DeepEqual(files, test.expectedFiles) {
+			t.Errorf("Config.Parse(%q): got files %q, expected %q", test.args, files, test.expectedFiles)
+		}
+		if len(rest) != 0 || len(test.expectedRest) != 0 {
+			if !reflect.DeepEqual(rest, test.expectedRest) {
+				t.Errorf("Config.Parse(%q): got rest %q, expected %q", test.args, rest, test.expectedRest)
+			}
+		}
+	}
+}
+
+func TestConfigParseRestRequired(t *testing.T) {
+	var rest []string
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags: []*Flag{
+			Required(Rest(&rest)),
+		},
+	}
+	b := &bytes.Buffer{}
+	c.FlagSet.SetOutput(b)
+
+	os.Clearenv()
+	err := c.Parse([]string{"foo"})
+	if err == nil {
+		t.Fatalf("Config.Parse(...): expected error, got nil")
+	}
+	for _, expected := range []string{"missing arguments after --", "Usage of flagset -- args...:"} {
+		if !strings.Contains(b.String(), expected) {
+			t.Errorf("Config.Parse(...): expected output %q to contain %q", b, expected)
+		}
+	}
+}
+
+func TestStructToFlagsRest(t *testing.T) {
+	var v struct {
+		Verbose bool     `flag:"verbose"`
+		Command []string `flag:",rest"`
+	}
+	flags, err := StructToFlags(&v)
+	if err != nil {
+		t.Fatalf("StructToFlags(%T): unexpected error: %s", v, err)
+	}
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags:   flags,
+	}
+	c.FlagSet.SetOutput(&bytes.Buffer{})
+
+	os.Clearenv()
+	args := []string{"-verbose", "--", "ls", "-l"}
+	err = c.Parse(args)
+	if err != nil {
+		t.Fatalf("Config.Parse(%q): unexpected error: %s", args, err)
+	}
+	if !v.Verbose || !reflect.DeepEqual(v.Command, []string{"ls", "-l"}) {
+		t.Errorf("Config.Parse(%q): got %+v, expected -verbose and [ls -l]", args, v)
+	}
+
+	var invalid struct {
+		Command string `flag:",rest"`
+	}
+	_, err = StructToFlags(&invalid)
+	if err == nil {
+		t.Errorf("StructToFlags(%T): expected error, got nil", invalid)
+	}
+}
@@ -0,0 +1,143 @@
+package rig
+
+import (
+	"fmt"
+)
+
+// A Rule is a constraint between flags, checked by Config.Parse once every source has been
+// consulted.
+type Rule interface {
+	// Check returns an error explaining the rule when it is not satisfied.
+	Check() error
+}
+
+// flagsRule is implemented by the Rules of this package, to check their flags against
+// Config.Flags (see Config.validateRules).
+type flagsRule interface {
+	Rule
+	flags() []*Flag
+}
+
+type requiredWith struct {
+	f      *Flag
+	others []*Flag
+}
+
+// RequiredWith creates a Rule requiring f to be set when any of the other flags is set, as in
+// "-tls-key is required when -tls-cert is set".
+func RequiredWith(f *Flag, others ...*Flag) Rule {
+	return requiredWith{f: f, others: others}
+}
+
+func (r requiredWith) flags() []*Flag {
+	return append([]*Flag{r.f}, r.others...)
+}
+
+func (r requiredWith) Check() error {
+	if r.f.set {
+		return nil
+	}
+
+	for _, other := range r.others {
+		if other.set {
+			return fmt.Errorf("%s is required when %s is set (%s)", flagDisplayName(r.f), flagDisplayName(other), other.Origin())
+		}
+	}
+
+	return nil
+}
+
+type requiredIf struct {
+	f     *Flag
+	other *Flag
+	value string
+}
+
+// RequiredIf creates a Rule requiring f to be set when the other flag has the value provided,
+// as in "-s3-bucket is required when -storage is s3". The value is compared to the String
+// method of the other flag's flag.Value.
+func RequiredIf(f, other *Flag, value string) Rule {
+	return requiredIf{f: f, other: other, value: value}
+}
+
+func (r requiredIf) flags() []*Flag {
+	return []*Flag{r.f, r.other}
+}
+
+func (r requiredIf) Check() error {
+	if r.f.set || unwrapValue(r.other.Value).String() != r.value {
+		return nil
+	}
+
+	value := r.value
+	if r.other.Secret {
+		value = maskSecret(value)
+	}
+
+	return fmt.Errorf("%s is required when %s is %q (%s)", flagDisplayName(r.f), flagDisplayName(r.other), value, r.other.Origin())
+}
+
+type conflictsWith struct {
+	f      *Flag
+	others []*Flag
+}
+
+// ConflictsWith creates a Rule preventing f from being set along with any of the other flags.
+func ConflictsWith(f *Flag, others ...*Flag) Rule {
+	return conflictsWith{f: f, others: others}
+}
+
+func (r conflictsWith) flags() []*Flag {
+	return append([]*Flag{r.f}, r.others...)
+}
+
+func (r conflictsWith) Check() error {
+	if !r.f.set {
+		return nil
+	}
+
+	for _, other := range r.others {
+		if other.set {
+			return fmt.Errorf("%s (%s) cannot be used with %s (%s)", flagDisplayName(r.f), r.f.Origin(), flagDisplayName(other), other.Origin())
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) checkRules() error {
+	err := c.validateRules()
+	if err != nil {
+		return err
+	}
+
+	errs := &ParseError{}
+	for _, rule := range c.Rules {
+		errs.add(rule.Check())
+	}
+
+	return errs.errorOrNil()
+}
+
+// validateRules rejects the Config.Rules referencing flags missing from Config.Flags, which
+// would never be set, as validateGroups does for the groups.
+func (c *Config) validateRules() error {
+	in := map[*Flag]bool{}
+	for _, f := range c.Flags {
+		in[f] = true
+	}
+
+	for _, rule := range c.Rules {
+		r, ok := rule.(flagsRule)
+		if !ok {
+			continue
+		}
+		for _, f := range r.flags() {
+			if !in[f] {
+				return fmt.Errorf("invalid rule: %s is not one of Config.Flags", flagDisplayName(f))
+			}
+		}
+	}
+
+	return nil
+}
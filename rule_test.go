@@ -0,0 +1,104 @@
+package rig
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"testing"
+)
+
+func TestConfigParseRules(t *testing.T) {
+	for _, test := range []struct {
+		args          []string
+		env           map[string]string
+		expectedError string
+	}{
+		{args: []string{}},
+		{args: []string{"-tls-cert", "cert.pem", "-tls-key", "key.pem"}},
+		{args: []string{"-storage", "s3", "-s3-bucket", "bucket"}},
+		{args: []string{"-storage", "fs"}},
+		{
+			args:          []string{"-tls-cert", "cert.pem"},
+			expectedError: "-tls-key is required when -tls-cert is set (command line flag -tls-cert)",
+		},
+		{
+			args:          []string{},
+			env:           map[string]string{"STORAGE": "s3"},
+			expectedError: `-s3-bucket is required when -storage is "s3" (env variable "STORAGE")`,
+		},
+		{
+			args:          []string{"-insecure", "-tls-cert", "cert.pem", "-tls-key", "key.pem"},
+			expectedError: "-insecure (command line flag -insecure) cannot be used with -tls-cert (command line flag -tls-cert)",
+		},
+	} {
+		var (
+			cert, key, storage, bucket string
+			insecure                   bool
+		)
+		certFlag := String(&cert, "tls-cert", "", "")
+		keyFlag := String(&key, "tls-key", "", "")
+		storageFlag := String(&storage, "storage", "STORAGE", "")
+		bucketFlag := String(&bucket, "s3-bucket", "", "")
+		insecureFlag := Bool(&insecure, "insecure", "", "")
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags:   []*Flag{certFlag, keyFlag, storageFlag, bucketFlag, insecureFlag},
+			Rules: []Rule{
+				RequiredWith(keyFlag, certFlag),
+				RequiredIf(bucketFlag, storageFlag, "s3"),
+				ConflictsWith(insecureFlag, certFlag, keyFlag),
+			},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		for k, v := range test.env {
+			os.Setenv(k, v)
+		}
+		err := c.Parse(test.args)
+		if test.expectedError == "" {
+			if err != nil {
+				t.Errorf("Config.Parse(%q): unexpected error: %s", test.args, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != test.expectedError {
+			t.Errorf("Config.Parse(%q): expected error %q, got %v", test.args, test.expectedError, err)
+		}
+	}
+}
+
+func TestRequiredIfSecret(t *testing.T) {
+	var mode, key string
+	modeFlag := Secret(String(&mode, "mode", "", ""))
+	keyFlag := String(&key, "key", "", "")
+	_ = modeFlag.Set("private")
+
+	err := RequiredIf(keyFlag, modeFlag, "private").Check()
+	if err == nil {
+		t.Fatalf("RequiredIf(...).Check(): expected error, got nil")
+	}
+	expected := `-key is required when -mode is "******" (command line flag -mode)`
+	if err.Error() != expected {
+		t.Errorf("RequiredIf(...).Check() = %q, expected %q", err, expected)
+	}
+}
+
+func TestConfigParseRulesUnknownFlag(t *testing.T) {
+	var a, b string
+	aFlag := String(&a, "a", "", "")
+	bFlag := String(&b, "b", "", "")
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags:   []*Flag{Required(aFlag), bFlag},
+		Rules:   []Rule{RequiredWith(bFlag, aFlag)},
+	}
+	c.FlagSet.SetOutput(&bytes.Buffer{})
+
+	os.Clearenv()
+	err := c.Parse([]string{"-a", "x"})
+	expected := "invalid rule: -a is not one of Config.Flags"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Config.Parse(...): expected error %q, got %v", expected, err)
+	}
+}
@@ -0,0 +1,56 @@
+package rig
+
+import (
+	"flag"
+)
+
+// Secret marks a flag as secret: its value is masked in the usage, in the parse errors, in
+// Config.Dump and by the String method of its flag.Value. The variable behind the flag still
+// receives the raw value.
+// Noop if Flag.Secret is true.
+func Secret(f *Flag) *Flag {
+	if f.Secret {
+		return f
+	}
+
+	ret := *f
+	ret.Secret = true
+	ret.Value = secretValue{Value: f.Value}
+	return &ret
+}
+
+type secretValue struct {
+	flag.Value
+}
+
+func (v secretValue) String() string {
+	return maskSecret(v.Value.String())
+}
+
+func (v secretValue) IsBoolFlag() bool {
+	boolFlagger, ok := v.Value.(isBoolFlagger)
+	return ok && boolFlagger.IsBoolFlag()
+}
+
+// unwrapValue returns the flag.Value wrapped by Secret, if any.
+func unwrapValue(v flag.Value) flag.Value {
+	if s, ok := v.(secretValue); ok {
+		return s.Value
+	}
+
+	return v
+}
+
+// maskedError hides the message of the error it wraps, which might include a secret value in
+// any form (quoted, escaped, truncated...). The error is only reachable through Unwrap.
+type maskedError struct {
+	err error
+}
+
+func (e maskedError) Error() string {
+	return "invalid value"
+}
+
+func (e maskedError) Unwrap() error {
+	return e.err
+}
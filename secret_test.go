@@ -0,0 +1,188 @@
+package rig
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSecret(t *testing.T) {
+	s := "hunter2"
+
+	f := String(&s, "token", "TOKEN", "testing Secret on String")
+	r := Secret(f)
+
+	if f.Secret {
+		t.Errorf("String(...).Secret = true, expected false")
+	}
+	if !r.Secret {
+		t.Errorf("Secret(String(...)).Secret = false, expected true")
+	}
+	if r.String() != secretMask {
+		t.Errorf("Secret(String(...)).String() = %q, expected %q", r.String(), secretMask)
+	}
+	if rr := Secret(r); rr != r {
+		t.Errorf("Secret(Secret(String(...))) should be a noop")
+	}
+
+	s = ""
+	if r.String() != "" {
+		t.Errorf("Secret(String(...)).String() = %q, expected empty values to be left as-is", r.String())
+	}
+
+	var b bool
+	if !Secret(Bool(&b, "bool", "", "")).IsBoolFlag() {
+		t.Errorf("Secret(Bool(...)).IsBoolFlag() = false, expected true")
+	}
+}
+
+func TestMaskedError(t *testing.T) {
+	errBase := errors.New(`invalid "hunter2"`)
+	err := error(maskedError{err: errBase})
+
+	if strings.Contains(err.Error(), "hunter") {
+		t.Errorf("maskedError.Error() = %q, expected the secret to be masked", err)
+	}
+	if !errors.Is(err, errBase) {
+		t.Errorf("errors.Is(maskedError, errBase) = false, expected true")
+	}
+}
+
+func TestConfigParseSecret(t *testing.T) {
+	const secret = "hunter2"
+
+	for _, test := range []struct {
+		name string
+		args []string
+		env  string
+	}{
+		{name: "args", args: []string{"-token=" + secret}},
+		{name: "env", args: []string{}, env: secret},
+		{name: "invalid args", args: []string{"-int-token=" + secret}},
+		{name: "invalid env", args: []string{}, env: secret + "x"},
+		{name: "invalid quoted args", args: []string{"-int-token=" + secret + `"`}},
+		{name: "invalid short args", args: []string{"-int-token=a"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var (
+				token    = "default-" + secret
+				intToken int
+			)
+			c := &Config{
+				FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+				Flags: []*Flag{
+					Secret(String(&token, "token", "", "")),
+					Secret(Int(&intToken, "int-token", "INT_TOKEN", "")),
+				},
+			}
+			buf := &bytes.Buffer{}
+			c.FlagSet.SetOutput(buf)
+
+			os.Clearenv()
+			if test.env != "" {
+				os.Setenv("INT_TOKEN", test.env)
+			}
+			err := c.Parse(test.args)
+			if err != nil && (strings.Contains(err.Error(), secret) || strings.Contains(err.Error(), "strconv")) {
+				t.Errorf("Config.Parse(...): error %q leaks the secret", err)
+			}
+			if strings.Contains(buf.String(), secret) {
+				t.Errorf("Config.Parse(...): output %q leaks the secret", buf)
+			}
+
+			_ = c.Dump(buf, DumpJSON)
+			if strings.Contains(buf.String(), secret) {
+				t.Errorf("Config.Dump(...): output %q leaks the secret", buf)
+			}
+			c.VisitAll(func(f *Flag) {
+				if strings.Contains(f.Origin().Raw, secret) {
+					t.Errorf("-%s: origin %+v leaks the secret", f.Name, f.Origin())
+				}
+			})
+		})
+	}
+
+	var token string
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags: []*Flag{
+			Secret(String(&token, "token", "", "")),
+		},
+	}
+	c.FlagSet.SetOutput(&bytes.Buffer{})
+	err := c.Parse([]string{"-token=" + secret})
+	if err != nil {
+		t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+	}
+	if token != secret {
+		t.Errorf("-token: got %q, expected %q", token, secret)
+	}
+}
+
+func TestConfigParseSecretInvalidValue(t *testing.T) {
+	var pin int
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags: []*Flag{
+			Secret(Int(&pin, "pin", "PIN", "")),
+		},
+	}
+	c.FlagSet.SetOutput(&bytes.Buffer{})
+
+	os.Clearenv()
+	err := c.Parse([]string{"-pin", "a"})
+	expected := "invalid value for command line flag -pin"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Config.Parse(...): expected error %q, got %v", expected, err)
+	}
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("Config.Parse(...): expected the cause to be reachable through Unwrap")
+	}
+}
+
+func TestConfigParseSecretErrorFields(t *testing.T) {
+	t.Run("validator", func(t *testing.T) {
+		var pin int
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Secret(Int(&pin, "pin", "PIN", "", func(int) error { return errors.New("too short") })),
+			},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		os.Setenv("PIN", "1234")
+		err := c.Parse([]string{})
+		var invalid *InvalidValueError
+		if !errors.As(err, &invalid) || invalid.Origin.Raw != secretMask {
+			t.Fatalf("Config.Parse(...): expected an *InvalidValueError with a masked Origin.Raw, got %#v", invalid)
+		}
+		var validation *ValidationError
+		if !errors.As(err, &validation) || validation.Value != secretMask {
+			t.Errorf("Config.Parse(...): expected a *ValidationError with a masked Value, got %#v", validation)
+		}
+	})
+
+	t.Run("unexpected positional", func(t *testing.T) {
+		var toks []string
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Secret(Arity(Positional(Repeatable(&toks, StringGenerator(), "tok", "", "")), 1, 1)),
+			},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		err := c.Parse([]string{"s3cr3t1", "s3cr3t2"})
+		var unexpected *UnexpectedPositionalError
+		if !errors.As(err, &unexpected) || unexpected.Raw != secretMask || strings.Contains(err.Error(), "s3cr3t") {
+			t.Errorf("Config.Parse(...): expected an *UnexpectedPositionalError hiding the value, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,285 @@
+package rig
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// The kinds of Origin used by rig. Custom sources are free to use their own.
+const (
+	OriginDefault    = "default"
+	OriginFlag       = "flag"
+	OriginPositional = "positional"
+	OriginEnv        = "env"
+	OriginEnvFile    = "env-file"
+	OriginFile       = "file"
+)
+
+// An Origin describes where a value came from.
+type Origin struct {
+	// Kind is one of the Origin* constants, or the kind defined by a custom Source.
+	Kind string
+	// Name is the flag name for OriginFlag and OriginPositional, the environment variable
+	// for OriginEnv and OriginEnvFile, and the key for OriginFile.
+	Name string
+	// File is the path of the file the value was read from, if any. Line is 0 when unknown.
+	File string
+	Line int
+	// Raw is the value, as provided by the source.
+	Raw string
+
+	gnu bool // the flag was given in GNU mode, as `--name`
+}
+
+func (o Origin) String() string {
+	s := ""
+	switch o.Kind {
+	default:
+		s = fmt.Sprintf("%s %q", o.Kind, o.Name)
+	case OriginDefault:
+		return "default value"
+	case OriginFlag:
+		s = "command line flag " + flagSpelling(o.Name, o.gnu)
+	case OriginPositional:
+		s = "positional argument " + o.Name
+	case OriginEnv:
+		s = fmt.Sprintf("env variable %q", o.Name)
+	case OriginEnvFile:
+		return fmt.Sprintf("env variable %q (file %q)", o.Name, o.File)
+	case OriginFile:
+		s = fmt.Sprintf("key %q", o.Name)
+	}
+
+	switch {
+	case o.File != "" && o.Line != 0:
+		s += fmt.Sprintf(" in file %q", fmt.Sprintf("%s:%d", o.File, o.Line))
+	case o.File != "":
+		s += fmt.Sprintf(" in file %q", o.File)
+	}
+
+	return s
+}
+
+// A SourceValue is a value provided by a Source.
+type SourceValue struct {
+	// Values holds a single value, unless List is true.
+	Values []string
+	// List values are set on repeatable flags element by element, instead of being split
+	// like the values coming from the command line or the environment.
+	List   bool
+	Origin Origin
+}
+
+// A Source is a layer of configuration consulted by Config.Parse for the flags that were not
+// set from the command line.
+type Source interface {
+	// Lookup returns the value for the flag provided. ok is false when the source doesn't
+	// have a value for this flag.
+	Lookup(f *Flag) (v SourceValue, ok bool, err error)
+}
+
+// SourceFunc is an adapter allowing the use of ordinary functions as a Source.
+type SourceFunc func(f *Flag) (SourceValue, bool, error)
+
+// Lookup calls fn(f).
+func (fn SourceFunc) Lookup(f *Flag) (SourceValue, bool, error) {
+	return fn(f)
+}
+
+type envSource struct{}
+
+// EnvSource returns a Source looking up the flags' environment variables in the process
+// environment. The Env of a flag is looked up first, followed by its EnvAliases.
+//
+// When a flag's variable is not set, EnvSource looks for the same variable suffixed with "_FILE"
+// (as in `DB_PASSWORD_FILE=/run/secrets/db-password`), and uses the contents of the file it
+// points to, with the leading and trailing white space removed.
+func EnvSource() Source {
+	return envSource{}
+}
+
+func (envSource) Lookup(f *Flag) (SourceValue, bool, error) {
+	for _, env := range f.envNames() {
+		v, ok := os.LookupEnv(env)
+		path, fileOk := os.LookupEnv(env + "_FILE")
+		switch {
+		case ok && fileOk:
+			return SourceValue{}, false, fmt.Errorf("env variables %q and %q cannot both be set", env, env+"_FILE")
+		case fileOk:
+			return lookupEnvFile(env+"_FILE", path)
+		case !ok:
+			continue
+		}
+
+		return SourceValue{
+			Values: []string{v},
+			Origin: Origin{
+				Kind: OriginEnv,
+				Name: env,
+				Raw:  v,
+			},
+		}, true, nil
+	}
+
+	return SourceValue{}, false, nil
+}
+
+func lookupEnvFile(env, path string) (SourceValue, bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return SourceValue{}, false, fmt.Errorf("reading file %q from env variable %q: %w", path, env, err)
+	}
+	v := strings.TrimSpace(string(data))
+
+	return SourceValue{
+		Values: []string{v},
+		Origin: Origin{
+			Kind: OriginEnvFile,
+			Name: env,
+			File: path,
+			Raw:  v,
+		},
+	}, true, nil
+}
+
+type filesSource struct {
+	c   *Config
+	env bool
+}
+
+// FilesSource returns a Source consulting the configuration files: the files selected by the
+// ConfigFile flags, followed by Config.Files. The first file providing a value for a flag wins.
+// The files are loaded by Config.Parse, once the ConfigFile flags are resolved. The dotenv files
+// are left to EnvFilesSource.
+func (c *Config) FilesSource() Source {
+	return filesSource{c: c}
+}
+
+// EnvFilesSource returns a Source consulting the dotenv files (see DotEnvFile) among the files
+// of FilesSource, in the same order.
+func (c *Config) EnvFilesSource() Source {
+	return filesSource{c: c, env: true}
+}
+
+func (s filesSource) Lookup(f *Flag) (SourceValue, bool, error) {
+	if f.configFile {
+		return SourceValue{}, false, nil
+	}
+
+	files := s.c.files
+	if s.env {
+		files = s.c.envFiles
+	}
+	for _, file := range files {
+		v, ok, err := file.Lookup(f)
+		if err != nil || ok {
+			return v, ok, err
+		}
+	}
+
+	return SourceValue{}, false, nil
+}
+
+// DefaultSources returns the Sources used when Config.Sources is nil: the process environment,
+// followed by the dotenv files and the other configuration files.
+func (c *Config) DefaultSources() []Source {
+	return []Source{
+		EnvSource(),
+		c.EnvFilesSource(),
+		c.FilesSource(),
+	}
+}
+
+func (c *Config) sources() []Source {
+	if c.Sources == nil {
+		return c.DefaultSources()
+	}
+
+	return c.Sources
+}
+
+// applySources sets the flags that haven't been set yet, using the first source providing
+// a value for each. The errors are collected in `errs`, so that every flag is resolved. A source
+// failing the same way for several flags, such as a File that cannot be read, is reported once.
+func (c *Config) applySources(flags []*Flag, errs *ParseError) {
+	type failure struct {
+		source int
+		err    string
+	}
+	failures := map[failure]*SourceError{}
+
+	for _, f := range flags {
+		if f.set || errs.failed(f) { // sources should not overwrite the command-line arguments
+			continue
+		}
+
+		for i, source := range c.sources() {
+			v, ok, err := source.Lookup(f)
+			if err != nil {
+				key := failure{source: i, err: err.Error()}
+				if failures[key] == nil {
+					failures[key] = &SourceError{Err: err}
+					errs.add(failures[key])
+				}
+				failures[key].Flags = append(failures[key].Flags, f)
+				break
+			}
+			if !ok {
+				continue
+			}
+
+			errs.add(c.setFromSource(f, v))
+			break
+		}
+	}
+}
+
+func (c *Config) setFromSource(f *Flag, v SourceValue) error {
+	if v.List && len(v.Values) == 0 {
+		return nil
+	}
+	if len(v.Values) == 0 {
+		return newInvalidValueError(f, v.Origin, errors.New("no value provided by the source"))
+	}
+	if v.Origin.Raw == "" {
+		v.Origin.Raw = v.Values[0]
+		if v.List {
+			v.Origin.Raw = strings.Join(v.Values, ",")
+		}
+	}
+
+	var err error
+	switch {
+	case v.List && !isSliceFlag(f):
+		return newInvalidValueError(f, v.Origin, errors.New("expected a single value"))
+	case v.List:
+		err = c.setList(f, v.Values)
+	default:
+		err = c.set(f, v.Values[0])
+	}
+	if err != nil {
+		if v.Origin.Kind == OriginEnvFile { // the file's contents should never be displayed
+			err = maskedError{err: err}
+		}
+		return newInvalidValueError(f, v.Origin, err)
+	}
+	f.origin = v.Origin
+
+	return nil
+}
+
+func invalidValueError(f *Flag, origin Origin, err error) error {
+	// the secrets and the files' contents should never be displayed: neither is the cause, as it
+	// might include the value in any form (quoted, escaped...)
+	if origin.Kind == OriginEnvFile || f.Secret {
+		return fmt.Errorf("invalid value for %s", origin)
+	}
+	if origin.Kind == OriginFile && f.Name != "" {
+		return fmt.Errorf("invalid value %q for %s (flag %s): %w", origin.Raw, origin, flagDisplayName(f), err)
+	}
+
+	return fmt.Errorf("invalid value %q for %s: %w", origin.Raw, origin, err)
+}
@@ -0,0 +1,340 @@
+package rig
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestOriginString(t *testing.T) {
+	for _, test := range []struct {
+		origin   Origin
+		expected string
+	}{
+		{origin: Origin{Kind: OriginDefault}, expected: "default value"},
+		{origin: Origin{Kind: OriginFlag, Name: "foo"}, expected: "command line flag -foo"},
+		{origin: Origin{Kind: OriginEnv, Name: "FOO"}, expected: `env variable "FOO"`},
+		{origin: Origin{Kind: OriginEnv, Name: "FOO", File: ".env", Line: 3}, expected: `env variable "FOO" in file ".env:3"`},
+		{origin: Origin{Kind: OriginFile, Name: "bar.foo", File: "config.toml"}, expected: `key "bar.foo" in file "config.toml"`},
+		{origin: Origin{Kind: "vault", Name: "secret/foo"}, expected: `vault "secret/foo"`},
+	} {
+		got := test.origin.String()
+		if got != test.expected {
+			t.Errorf("%#v.String() = %q, expected %q", test.origin, got, test.expected)
+		}
+	}
+}
+
+func TestEnvSource(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("FOO", "bar")
+
+	var s string
+	v, ok, err := EnvSource().Lookup(String(&s, "foo", "FOO", ""))
+	if err != nil {
+		t.Fatalf("EnvSource().Lookup(...): unexpected error: %s", err)
+	}
+	if !ok {
+		t.Fatalf("EnvSource().Lookup(...): expected a value")
+	}
+	expected := Origin{Kind: OriginEnv, Name: "FOO", Raw: "bar"}
+	if len(v.Values) != 1 || v.Values[0] != "bar" || v.List || v.Origin != expected {
+		t.Errorf("EnvSource().Lookup(...) = %+v, expected %q from %+v", v, "bar", expected)
+	}
+
+	for _, f := range []*Flag{
+		String(&s, "foo", "", ""),
+		String(&s, "foo", "BAR", ""),
+	} {
+		_, ok, err := EnvSource().Lookup(f)
+		if err != nil || ok {
+			t.Errorf("EnvSource().Lookup(%+v) = _, %v, %v, expected no value", f, ok, err)
+		}
+	}
+}
+
+func TestConfigParseSources(t *testing.T) {
+	mapSource := func(kind string, values map[string]string) Source {
+		return SourceFunc(func(f *Flag) (SourceValue, bool, error) {
+			v, ok := values[f.Name]
+			if !ok {
+				return SourceValue{}, false, nil
+			}
+
+			return SourceValue{
+				Values: []string{v},
+				Origin: Origin{Kind: kind, Name: f.Name},
+			}, true, nil
+		})
+	}
+
+	t.Run("order", func(t *testing.T) {
+		var (
+			s1, s2, s3 string
+			ss         []string
+		)
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				String(&s1, "string-1", "STRING_1", ""),
+				String(&s2, "string-2", "STRING_2", ""),
+				String(&s3, "string-3", "STRING_3", ""),
+				Repeatable(&ss, StringGenerator(), "strings", "", ""),
+			},
+			Sources: []Source{
+				mapSource("first", map[string]string{"string-1": "first"}),
+				EnvSource(),
+				mapSource("last", map[string]string{"string-1": "last", "string-2": "last"}),
+				SourceFunc(func(f *Flag) (SourceValue, bool, error) {
+					if f.Name != "strings" {
+						return SourceValue{}, false, nil
+					}
+					return SourceValue{Values: []string{"a,b", "c"}, List: true}, true, nil
+				}),
+			},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		os.Setenv("STRING_1", "env")
+		os.Setenv("STRING_2", "env")
+		err := c.Parse([]string{"-string-3=args"})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+
+		for _, test := range []struct {
+			name, got, expected string
+		}{
+			{"string-1", s1, "first"},
+			{"string-2", s2, "env"},
+			{"string-3", s3, "args"},
+		} {
+			if test.got != test.expected {
+				t.Errorf("-%s: got %q, expected %q", test.name, test.got, test.expected)
+			}
+		}
+		if len(ss) != 2 || ss[0] != "a,b" || ss[1] != "c" {
+			t.Errorf("-strings: got %q, expected %q", ss, []string{"a,b", "c"})
+		}
+	})
+
+	t.Run("environment ignored", func(t *testing.T) {
+		var s string
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				String(&s, "string-1", "STRING_1", ""),
+			},
+			Sources: []Source{},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		os.Setenv("STRING_1", "env")
+		err := c.Parse([]string{})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+		if s != "" {
+			t.Errorf("-string-1: got %q, expected it to be left unset", s)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var i int
+		errSource := errors.New("source error")
+		for _, source := range []Source{
+			SourceFunc(func(f *Flag) (SourceValue, bool, error) {
+				return SourceValue{}, false, errSource
+			}),
+			mapSource("custom", map[string]string{"int-flag": "foo"}),
+		} {
+			c := &Config{
+				FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+				Flags: []*Flag{
+					Int(&i, "int-flag", "", ""),
+				},
+				Sources: []Source{source},
+			}
+			c.FlagSet.SetOutput(&bytes.Buffer{})
+
+			err := c.Parse([]string{})
+			if err == nil {
+				t.Errorf("Config.Parse(...): expected error, got nil")
+				continue
+			}
+			if !errors.Is(err, errSource) && !strings.Contains(err.Error(), `custom "int-flag"`) {
+				t.Errorf("Config.Parse(...): expected error to describe the origin, got %q", err)
+			}
+		}
+	})
+
+	t.Run("failing file", func(t *testing.T) {
+		var a, b, c1 string
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				String(&a, "a", "", ""),
+				String(&b, "b", "", ""),
+				String(&c1, "c", "", ""),
+			},
+			Sources: []Source{EnvSource(), JSONFile("/missing.json")},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		err := c.Parse([]string{})
+		var parseErr *ParseError
+		var sourceErr *SourceError
+		if !errors.As(err, &parseErr) || len(parseErr.Errors) != 1 || !errors.As(err, &sourceErr) || len(sourceErr.Flags) != 3 {
+			t.Fatalf("Config.Parse(...): expected a single *SourceError for the 3 flags, got %v", err)
+		}
+		var invalid *InvalidValueError
+		if errors.As(err, &invalid) {
+			t.Errorf("Config.Parse(...): expected no *InvalidValueError, got %v", invalid)
+		}
+	})
+
+	t.Run("files not consulted", func(t *testing.T) {
+		var a string
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				String(&a, "a", "A", ""),
+			},
+			Files:   []*File{JSONFile("/missing.json")},
+			Sources: []Source{EnvSource()},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		os.Setenv("A", "x")
+		err := c.Parse([]string{})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+		if a != "x" {
+			t.Errorf("A: got %q, expected %q", a, "x")
+		}
+	})
+
+	t.Run("no value", func(t *testing.T) {
+		var i int
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Int(&i, "int-flag", "", ""),
+			},
+			Sources: []Source{
+				SourceFunc(func(f *Flag) (SourceValue, bool, error) {
+					return SourceValue{}, true, nil
+				}),
+			},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		err := c.Parse([]string{})
+		var invalid *InvalidValueError
+		if !errors.As(err, &invalid) || invalid.Flag != c.Flags[0] {
+			t.Errorf("Config.Parse(...): expected an *InvalidValueError for -int-flag, got %v", err)
+		}
+	})
+}
+
+func TestEnvSourceFile(t *testing.T) {
+	path := writeTestFile(t, "password", "hunter2\n")
+
+	t.Run("valid", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("PASSWORD_FILE", path)
+
+		var s string
+		v, ok, err := EnvSource().Lookup(String(&s, "password", "PASSWORD", ""))
+		if err != nil {
+			t.Fatalf("EnvSource().Lookup(...): unexpected error: %s", err)
+		}
+		if !ok {
+			t.Fatalf("EnvSource().Lookup(...): expected a value")
+		}
+		expected := Origin{Kind: OriginEnvFile, Name: "PASSWORD_FILE", File: path, Raw: "hunter2"}
+		if len(v.Values) != 1 || v.Values[0] != "hunter2" || v.Origin != expected {
+			t.Errorf("EnvSource().Lookup(...) = %+v, expected %q from %+v", v, "hunter2", expected)
+		}
+	})
+
+	for _, test := range []struct {
+		name string
+		env  map[string]string
+	}{
+		{name: "both set", env: map[string]string{"PASSWORD": "foo", "PASSWORD_FILE": path}},
+		{name: "missing file", env: map[string]string{"PASSWORD_FILE": path + ".missing"}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			os.Clearenv()
+			for k, v := range test.env {
+				os.Setenv(k, v)
+			}
+
+			var s string
+			_, _, err := EnvSource().Lookup(String(&s, "password", "PASSWORD", ""))
+			if err == nil {
+				t.Errorf("EnvSource().Lookup(...): expected error, got nil")
+			}
+		})
+	}
+
+	t.Run("invalid value", func(t *testing.T) {
+		os.Clearenv()
+		os.Setenv("PASSWORD_FILE", path)
+
+		var i int
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Int(&i, "password", "PASSWORD", ""),
+			},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		err := c.Parse([]string{})
+		if err == nil {
+			t.Fatalf("Config.Parse(...): expected error, got nil")
+		}
+		if !strings.Contains(err.Error(), path) || !strings.Contains(err.Error(), "PASSWORD_FILE") {
+			t.Errorf("Config.Parse(...): expected error %q to name the file and env variable", err)
+		}
+		if strings.Contains(err.Error(), "hunter2") {
+			t.Errorf("Config.Parse(...): error %q leaks the file's contents", err)
+		}
+	})
+
+	t.Run("invalid escaped value", func(t *testing.T) {
+		path := writeTestFile(t, "password", "hunter\"2\nhunter3")
+		os.Clearenv()
+		os.Setenv("PASSWORD_FILE", path)
+
+		var i int
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Int(&i, "password", "PASSWORD", ""),
+			},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		err := c.Parse([]string{})
+		expected := fmt.Sprintf(`invalid value for env variable "PASSWORD_FILE" (file %q)`, path)
+		if err == nil || err.Error() != expected {
+			t.Fatalf("Config.Parse(...): expected error %q, got %v", expected, err)
+		}
+		if !errors.Is(err, strconv.ErrSyntax) {
+			t.Errorf("Config.Parse(...): expected the cause to be reachable through Unwrap")
+		}
+	})
+}
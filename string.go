@@ -17,7 +17,7 @@ func (v stringValidators) Set(s string) error {
 	for _, validator := range v.validators {
 		err := validator(string(*v.stringValue))
 		if err != nil {
-			return err
+			return &ValidationError{Value: s, Err: err}
 		}
 	}
 
@@ -0,0 +1,152 @@
+package rig
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// suggest returns the candidates close enough to `s` to be likely typos, the closest first.
+func suggest(s string, candidates []string) []string {
+	type suggestion struct {
+		s        string
+		distance int
+	}
+
+	maxDistance := len(s) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	suggestions := []suggestion{}
+	seen := map[string]bool{}
+	for _, candidate := range candidates {
+		if candidate == "" || seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		d := editDistance(strings.ToLower(s), strings.ToLower(candidate))
+		if d <= maxDistance {
+			suggestions = append(suggestions, suggestion{s: candidate, distance: d})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].distance < suggestions[j].distance
+	})
+
+	ret := make([]string, 0, len(suggestions))
+	for _, s := range suggestions {
+		ret = append(ret, s.s)
+	}
+
+	return ret
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+func minInt(first int, others ...int) int {
+	ret := first
+	for _, v := range others {
+		if v < ret {
+			ret = v
+		}
+	}
+
+	return ret
+}
+
+// didYouMean renders the suggestions as " (did you mean -a or -b?)", or "" when there are none.
+func didYouMean(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+
+	return " (did you mean " + joinWords(suggestions, "or") + "?)"
+}
+
+// suggestFlags returns the flags that were likely meant instead of the undefined flag `arg`,
+// spelled the way they are expected on the command line.
+func (c *Config) suggestFlags(arg string) []string {
+	name := strings.TrimLeft(arg, "-")
+	candidates := []string{}
+	spellings := map[string]string{}
+	for _, f := range c.Flags {
+		if f.Name == "" || f.rest {
+			continue
+		}
+
+		for _, name := range f.names() {
+			candidates = append(candidates, name)
+			spellings[name] = c.flagSpelling(name)
+		}
+		if f.Short != "" {
+			candidates = append(candidates, f.Short)
+			spellings[f.Short] = "-" + f.Short
+		}
+	}
+
+	suggestions := suggest(name, candidates)
+	for i, s := range suggestions {
+		suggestions[i] = spellings[s]
+	}
+
+	return suggestions
+}
+
+// checkEnv returns an UnknownEnvError for each variable of the process environment starting
+// with Config.EnvPrefix that doesn't match the Env of any of the flags.
+func (c *Config) checkEnv() error {
+	if c.EnvPrefix == "" {
+		return nil
+	}
+
+	known := map[string]bool{}
+	candidates := []string{}
+	for _, f := range c.Flags {
+		for _, env := range f.envNames() {
+			known[env] = true
+			known[env+"_FILE"] = true // see EnvSource
+			candidates = append(candidates, env)
+		}
+	}
+
+	errs := &ParseError{}
+	env := os.Environ()
+	sort.Strings(env)
+	for _, kv := range env {
+		name := strings.SplitN(kv, "=", 2)[0]
+		if !strings.HasPrefix(name, c.EnvPrefix) || known[name] {
+			continue
+		}
+
+		errs.add(&UnknownEnvError{
+			Name:        name,
+			Suggestions: suggest(name, candidates),
+		})
+	}
+
+	return errs.errorOrNil()
+}
@@ -0,0 +1,219 @@
+package rig
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	for _, test := range []struct {
+		a, b     string
+		expected int
+	}{
+		{a: "", b: "", expected: 0},
+		{a: "flag", b: "", expected: 4},
+		{a: "flag-a", b: "flag-b", expected: 1},
+		{a: "verbose", b: "verbsoe", expected: 2},
+		{a: "kitten", b: "sitting", expected: 3},
+	} {
+		got := editDistance(test.a, test.b)
+		if got != test.expected {
+			t.Errorf("editDistance(%q, %q) = %d, expected %d", test.a, test.b, got, test.expected)
+		}
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"port", "host", "hosts", "verbose", "output"}
+	for _, test := range []struct {
+		s        string
+		expected []string
+	}{
+		{s: "prot", expected: []string{"port"}},
+		{s: "hots", expected: []string{"hosts", "host"}},
+		{s: "verbos", expected: []string{"verbose"}},
+		{s: "nothing-like-it", expected: []string{}},
+	} {
+		got := suggest(test.s, candidates)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("suggest(%q, %q) = %q, expected %q", test.s, candidates, got, test.expected)
+		}
+	}
+}
+
+func TestConfigParseUnknownFlag(t *testing.T) {
+	var a, b bool
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags: []*Flag{
+			Bool(&a, "flag-a", "", ""),
+			Short(Bool(&b, "flag-b", "", ""), "b"),
+		},
+	}
+	c.FlagSet.SetOutput(&bytes.Buffer{})
+
+	os.Clearenv()
+	err := c.Parse([]string{"-flag-c"})
+	expected := "flag provided but not defined: -flag-c (did you mean -flag-a or -flag-b?)"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("Config.Parse(...): expected error %q, got %v", expected, err)
+	}
+
+	var unknown *UnknownFlagError
+	if !errors.As(err, &unknown) || unknown.Arg != "-flag-c" {
+		t.Errorf("Config.Parse(...): expected an *UnknownFlagError for -flag-c, got %+v", unknown)
+	}
+}
+
+func TestConfigParseUnknownFlagOutput(t *testing.T) {
+	var a bool
+	newConfig := func() (*Config, *bytes.Buffer) {
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Bool(&a, "flag-a", "", ""),
+			},
+		}
+		b := &bytes.Buffer{}
+		c.FlagSet.SetOutput(b)
+		return c, b
+	}
+
+	os.Clearenv()
+	c, b := newConfig()
+	_ = c.Parse([]string{"-flag-b"})
+	output := b.String()
+	expected := "flag provided but not defined: -flag-b (did you mean -flag-a?)\n"
+	if !strings.HasPrefix(output, expected) || strings.Count(output, "not defined") != 1 || strings.Count(output, "Usage of") != 1 {
+		t.Errorf("Config.Parse(...): expected the error to be reported once, with the usage, got %q", output)
+	}
+
+	c, b = newConfig()
+	err := c.Parse([]string{"-help"})
+	if err != flag.ErrHelp || strings.Count(b.String(), "Usage of") != 1 || strings.Contains(b.String(), "help requested") {
+		t.Errorf("Config.Parse(...): expected flag.ErrHelp and the usage printed once, got %v and %q", err, b.String())
+	}
+}
+
+const testUnknownFlagExitOnErrorEnv = "TEST_UNKNOWN_FLAG_CRASHER"
+
+func TestUnknownFlagExitOnErrorCrasher(t *testing.T) {
+	if os.Getenv(testUnknownFlagExitOnErrorEnv) != "1" {
+		t.SkipNow()
+	}
+
+	var a bool
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ExitOnError),
+		Flags: []*Flag{
+			Bool(&a, "flag-a", "", ""),
+		},
+	}
+	_ = c.Parse([]string{"-flag-b"})
+	t.Logf("should have os.Exit, this code shouldn't have been reached")
+}
+
+func TestConfigParseUnknownFlagExitOnError(t *testing.T) {
+	buf := &bytes.Buffer{}
+	cmd := exec.Command(os.Args[0], "-test.run=TestUnknownFlagExitOnErrorCrasher")
+	cmd.Env = append(os.Environ(), testUnknownFlagExitOnErrorEnv+"=1")
+	cmd.Stdout = ioutil.Discard
+	cmd.Stderr = buf
+
+	err := cmd.Run()
+	if e, ok := err.(*exec.ExitError); !ok || e.ExitCode() != 2 {
+		t.Errorf("expected process to exit with status 2, got %v", err)
+	}
+	expected := "flag provided but not defined: -flag-b (did you mean -flag-a?)\n"
+	if !strings.HasPrefix(buf.String(), expected) {
+		t.Errorf("expected output to start with %q, got %q", expected, buf.String())
+	}
+}
+
+func TestConfigParseEnvTypos(t *testing.T) {
+	var port int
+	newConfig := func() *Config {
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Required(Int(&port, "port", "APP_PORT", "")),
+			},
+			EnvPrefix: "APP_",
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+		return c
+	}
+
+	os.Clearenv()
+	os.Setenv("APP_PROT", "8080")
+	os.Setenv("APP_UNRELATED", "foo")
+	os.Setenv("OTHER_PORT", "8080")
+	err := newConfig().Parse([]string{})
+	if err == nil {
+		t.Fatalf("Config.Parse(...): expected error, got nil")
+	}
+	for _, expected := range []string{
+		"unknown environment variable APP_PROT (did you mean APP_PORT?)",
+		"unknown environment variable APP_UNRELATED",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Config.Parse(...): expected error %q to contain %q", err, expected)
+		}
+	}
+	if strings.Contains(err.Error(), "OTHER_PORT") {
+		t.Errorf("Config.Parse(...): expected error %q not to mention OTHER_PORT", err)
+	}
+	var unknown *UnknownEnvError
+	if !errors.As(err, &unknown) || unknown.Name != "APP_PROT" {
+		t.Errorf("Config.Parse(...): expected an *UnknownEnvError for APP_PROT, got %+v", unknown)
+	}
+
+	os.Setenv("APP_PORT", "8080")
+	err = newConfig().Parse([]string{})
+	if err != nil {
+		t.Errorf("Config.Parse(...): unexpected error: %s", err)
+	}
+}
+
+func TestConfigParseStrictEnv(t *testing.T) {
+	var port int
+	newConfig := func(strict bool) *Config {
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Int(&port, "port", "MYAPP_PORT", ""),
+			},
+			EnvPrefix: "MYAPP_",
+			StrictEnv: strict,
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+		return c
+	}
+
+	os.Clearenv()
+	os.Setenv("MYAPP_PORT", "8080")
+	os.Setenv("MYAPP_DB_HOTS", "localhost")
+	err := newConfig(false).Parse([]string{})
+	if err != nil {
+		t.Errorf("Config.Parse(...): unexpected error without StrictEnv: %s", err)
+	}
+
+	err = newConfig(true).Parse([]string{})
+	expected := "unknown environment variable MYAPP_DB_HOTS"
+	if err == nil || err.Error() != expected {
+		t.Errorf("Config.Parse(...): expected error %q with StrictEnv, got %v", expected, err)
+	}
+
+	os.Unsetenv("MYAPP_DB_HOTS")
+	err = newConfig(true).Parse([]string{})
+	if err != nil {
+		t.Errorf("Config.Parse(...): unexpected error with StrictEnv: %s", err)
+	}
+}
@@ -0,0 +1,99 @@
+package rig
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// TOMLFile creates a File for the TOML document at `path`.
+// Tables map onto the prefixes of nested structs, and arrays are set on repeatable flags
+// element by element.
+func TOMLFile(path string) *File {
+	return &File{
+		Path:   path,
+		decode: decodeTOML,
+	}
+}
+
+func decodeTOML(data []byte) ([]*fileEntry, error) {
+	var doc map[string]interface{}
+	_, err := toml.Decode(string(data), &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*fileEntry
+	err = decodeTOMLTable(doc, nil, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func decodeTOMLTable(table map[string]interface{}, path []string, entries *[]*fileEntry) error {
+	keys := make([]string, 0, len(table))
+	for k := range table {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		keyPath := append(append([]string{}, path...), k)
+
+		switch v := table[k].(type) {
+		case map[string]interface{}:
+			err := decodeTOMLTable(v, keyPath, entries)
+			if err != nil {
+				return err
+			}
+		case []interface{}:
+			e := &fileEntry{
+				path:   keyPath,
+				values: make([]string, 0, len(v)),
+				list:   true,
+			}
+			for _, item := range v {
+				s, ok := tomlScalar(item)
+				if !ok {
+					return fmt.Errorf("arrays can only contain scalar values (key %q)", strings.Join(keyPath, "."))
+				}
+				e.values = append(e.values, s)
+			}
+			*entries = append(*entries, e)
+		default:
+			s, ok := tomlScalar(v)
+			if !ok {
+				return fmt.Errorf("unsupported value for key %q", strings.Join(keyPath, "."))
+			}
+			*entries = append(*entries, &fileEntry{
+				path:   keyPath,
+				values: []string{s},
+			})
+		}
+	}
+
+	return nil
+}
+
+func tomlScalar(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	default:
+		return "", false
+	case string:
+		return t, true
+	case int64:
+		return strconv.FormatInt(t, 10), true
+	case float64:
+		return strconv.FormatFloat(t, 'g', -1, 64), true
+	case bool:
+		return strconv.FormatBool(t), true
+	case time.Time:
+		return t.Format(time.RFC3339Nano), true
+	}
+}
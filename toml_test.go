@@ -0,0 +1,124 @@
+package rig
+
+import (
+	"bytes"
+	"flag"
+	"net/url"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/Pimmr/rig/validators"
+)
+
+func TestDecodeTOML(t *testing.T) {
+	for _, test := range []struct {
+		in       string
+		expected []*fileEntry
+	}{
+		{
+			in:       "",
+			expected: nil,
+		},
+		{
+			in: "string-flag = \"foo\"\nint-flag = 42\nfloat-flag = 1.5\nbool-flag = true\n",
+			expected: []*fileEntry{
+				{path: []string{"bool-flag"}, values: []string{"true"}},
+				{path: []string{"float-flag"}, values: []string{"1.5"}},
+				{path: []string{"int-flag"}, values: []string{"42"}},
+				{path: []string{"string-flag"}, values: []string{"foo"}},
+			},
+		},
+		{
+			in: "ints = [1, 2]\n[bar]\nflag-e = 3\n",
+			expected: []*fileEntry{
+				{path: []string{"bar", "flag-e"}, values: []string{"3"}},
+				{path: []string{"ints"}, values: []string{"1", "2"}, list: true},
+			},
+		},
+		{
+			in: "bar = {flag-e = 3}\n",
+			expected: []*fileEntry{
+				{path: []string{"bar", "flag-e"}, values: []string{"3"}},
+			},
+		},
+	} {
+		got, err := decodeTOML([]byte(test.in))
+		if err != nil {
+			t.Errorf("decodeTOML(%q): unexpected error: %s", test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("decodeTOML(%q) = %+v, expected %+v", test.in, got, test.expected)
+		}
+	}
+
+	for _, in := range []string{
+		"foo = ",
+		"[[foo]]\nbar = 1\n",
+		"foo = [[1], [2]]\n",
+	} {
+		_, err := decodeTOML([]byte(in))
+		if err == nil {
+			t.Errorf("decodeTOML(%q): expected error, got nil", in)
+		}
+	}
+}
+
+func TestConfigParseTOMLFile(t *testing.T) {
+	t.Run("valid", func(t *testing.T) {
+		path := writeTestFile(t, "config.toml", `
+urls = ["https://example.com", "https://example.org"]
+
+[bar]
+flag-e = 3
+`)
+
+		var (
+			e    int
+			urls []*url.URL
+		)
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Int(&e, "bar-flag-e", "", "", validators.IntMin(1)),
+				Repeatable(&urls, URLGenerator(), "urls", "", ""),
+			},
+			Files: []*File{TOMLFile(path)},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		err := c.Parse([]string{})
+		if err != nil {
+			t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+		}
+
+		if e != 3 {
+			t.Errorf("-bar-flag-e: got %d, expected %d", e, 3)
+		}
+		if len(urls) != 2 || urls[0].Host != "example.com" || urls[1].Host != "example.org" {
+			t.Errorf("-urls: got %v, expected 2 URLs", urls)
+		}
+	})
+
+	t.Run("validators", func(t *testing.T) {
+		path := writeTestFile(t, "config.toml", "[bar]\nflag-e = 0\n")
+
+		var e int
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags: []*Flag{
+				Int(&e, "bar-flag-e", "", "", validators.IntMin(1)),
+			},
+			Files: []*File{TOMLFile(path)},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		err := c.Parse([]string{})
+		if err == nil {
+			t.Errorf("Config.Parse(...): expected validation error, got nil")
+		}
+	})
+}
@@ -21,7 +21,7 @@ func (v uintValidators) Set(s string) error {
 	for _, validator := range v.validators {
 		err = validator(uint(*v.uintValue))
 		if err != nil {
-			return err
+			return &ValidationError{Value: s, Err: err}
 		}
 	}
 
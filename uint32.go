@@ -21,7 +21,7 @@ func (v uint32Validators) Set(s string) error {
 	for _, validator := range v.validators {
 		err = validator(uint32(*v.uint32Value))
 		if err != nil {
-			return err
+			return &ValidationError{Value: s, Err: err}
 		}
 	}
 
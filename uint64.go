@@ -21,7 +21,7 @@ func (v uint64Validators) Set(s string) error {
 	for _, validator := range v.validators {
 		err = validator(uint64(*v.uint64Value))
 		if err != nil {
-			return err
+			return &ValidationError{Value: s, Err: err}
 		}
 	}
 
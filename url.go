@@ -21,7 +21,7 @@ func (v urlValidators) Set(s string) error {
 	for _, validator := range v.validators {
 		err = validator(*v.urlValue.URL)
 		if err != nil {
-			return err
+			return &ValidationError{Value: s, Err: err}
 		}
 	}
 
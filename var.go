@@ -21,7 +21,7 @@ func (v varValidators) Set(s string) error {
 	for _, validator := range v.validators {
 		err = validator(v.Value)
 		if err != nil {
-			return err
+			return &ValidationError{Value: s, Err: err}
 		}
 	}
 
@@ -0,0 +1,150 @@
+package rig
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLFile creates a File for the YAML document at `path`.
+// Sequences are set on repeatable flags element by element, without the comma splitting
+// applied to command-line and environment values.
+func YAMLFile(path string) *File {
+	return &File{
+		Path:   path,
+		decode: decodeYAML,
+	}
+}
+
+func decodeYAML(data []byte) ([]*fileEntry, error) {
+	var doc yaml.Node
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	root := resolveYAMLAlias(doc.Content[0])
+	if root.Kind != yaml.MappingNode {
+		return nil, errors.New("expected a mapping at the top level")
+	}
+
+	var entries []*fileEntry
+	err = decodeYAMLMapping(root, nil, &entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func decodeYAMLMapping(node *yaml.Node, path []string, entries *[]*fileEntry) error {
+	var merged []*fileEntry
+	defer func() {
+		*entries = appendYAMLMerged(*entries, merged)
+	}()
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], resolveYAMLAlias(node.Content[i+1])
+		keyPath := append(append([]string{}, path...), key.Value)
+
+		if key.ShortTag() == "!!merge" {
+			err := decodeYAMLMerge(value, path, &merged)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch value.Kind {
+		default:
+			return fmt.Errorf("line %d: unsupported value for key %q", key.Line, strings.Join(keyPath, "."))
+		case yaml.MappingNode:
+			err := decodeYAMLMapping(value, keyPath, entries)
+			if err != nil {
+				return err
+			}
+		case yaml.SequenceNode:
+			e := &fileEntry{
+				path:   keyPath,
+				line:   key.Line,
+				values: make([]string, 0, len(value.Content)),
+				list:   true,
+			}
+			for _, item := range value.Content {
+				item = resolveYAMLAlias(item)
+				if item.Kind != yaml.ScalarNode || isYAMLNull(item) {
+					return fmt.Errorf("line %d: sequences can only contain scalar values (key %q)", item.Line, e.key())
+				}
+				e.values = append(e.values, item.Value)
+			}
+			*entries = append(*entries, e)
+		case yaml.ScalarNode:
+			if isYAMLNull(value) {
+				continue
+			}
+			*entries = append(*entries, &fileEntry{
+				path:   keyPath,
+				line:   key.Line,
+				values: []string{value.Value},
+			})
+		}
+	}
+
+	return nil
+}
+
+// decodeYAMLMerge handles merge keys (`<<: *base`), which can reference either a mapping
+// or a sequence of mappings.
+func decodeYAMLMerge(node *yaml.Node, path []string, entries *[]*fileEntry) error {
+	if node.Kind == yaml.MappingNode {
+		return decodeYAMLMapping(node, path, entries)
+	}
+	if node.Kind != yaml.SequenceNode {
+		return fmt.Errorf("line %d: merge keys can only reference mappings", node.Line)
+	}
+
+	for _, item := range node.Content {
+		err := decodeYAMLMerge(resolveYAMLAlias(item), path, entries)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// appendYAMLMerged appends the entries coming from merge keys, unless they are overridden
+// by the mapping itself.
+func appendYAMLMerged(entries, merged []*fileEntry) []*fileEntry {
+	defined := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		defined[e.name()] = true
+	}
+
+	for _, e := range merged {
+		if defined[e.name()] {
+			continue
+		}
+		defined[e.name()] = true
+		entries = append(entries, e)
+	}
+
+	return entries
+}
+
+func resolveYAMLAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+
+	return node
+}
+
+func isYAMLNull(node *yaml.Node) bool {
+	return node.ShortTag() == "!!null"
+}
@@ -0,0 +1,158 @@
+package rig
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestDecodeYAML(t *testing.T) {
+	for _, test := range []struct {
+		in       string
+		expected []*fileEntry
+	}{
+		{
+			in:       "",
+			expected: nil,
+		},
+		{
+			in: "string-flag: foo\nint-flag: 42\nnull-flag: ~\n",
+			expected: []*fileEntry{
+				{path: []string{"string-flag"}, line: 1, values: []string{"foo"}},
+				{path: []string{"int-flag"}, line: 2, values: []string{"42"}},
+			},
+		},
+		{
+			in: "bar:\n  flag-e: 3\nints:\n  - 1\n  - 2\n",
+			expected: []*fileEntry{
+				{path: []string{"bar", "flag-e"}, line: 2, values: []string{"3"}},
+				{path: []string{"ints"}, line: 3, values: []string{"1", "2"}, list: true},
+			},
+		},
+		{
+			in: "base: &base\n  a: 1\n  b: 2\nbar:\n  <<: *base\n  b: 3\n",
+			expected: []*fileEntry{
+				{path: []string{"base", "a"}, line: 2, values: []string{"1"}},
+				{path: []string{"base", "b"}, line: 3, values: []string{"2"}},
+				{path: []string{"bar", "b"}, line: 6, values: []string{"3"}},
+				{path: []string{"bar", "a"}, line: 2, values: []string{"1"}},
+			},
+		},
+	} {
+		got, err := decodeYAML([]byte(test.in))
+		if err != nil {
+			t.Errorf("decodeYAML(%q): unexpected error: %s", test.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("decodeYAML(%q) = %+v, expected %+v", test.in, got, test.expected)
+		}
+	}
+
+	for _, in := range []string{
+		"- foo\n",
+		"foo: [\n",
+		"foo:\n  - bar: 1\n",
+	} {
+		_, err := decodeYAML([]byte(in))
+		if err == nil {
+			t.Errorf("decodeYAML(%q): expected error, got nil", in)
+		}
+	}
+}
+
+func TestConfigParseYAMLFile(t *testing.T) {
+	path := writeTestFile(t, "config.yaml", `
+flag-a: from-file
+bar:
+  flag-e: 3
+strings:
+  - a,b
+  - c\d
+`)
+
+	var v struct {
+		FlagA   string
+		Strings []string
+		Bar     struct {
+			FlagE int
+		}
+	}
+
+	flags, err := StructToFlags(&v)
+	if err != nil {
+		t.Fatalf("StructToFlags(...): unexpected error: %s", err)
+	}
+	c := &Config{
+		FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+		Flags:   flags,
+		Files:   []*File{YAMLFile(path)},
+	}
+	c.FlagSet.SetOutput(&bytes.Buffer{})
+
+	os.Clearenv()
+	err = c.Parse([]string{})
+	if err != nil {
+		t.Fatalf("Config.Parse(...): unexpected error: %s", err)
+	}
+
+	if v.FlagA != "from-file" {
+		t.Errorf("-flag-a: got %q, expected %q", v.FlagA, "from-file")
+	}
+	if v.Bar.FlagE != 3 {
+		t.Errorf("-bar-flag-e: got %d, expected %d", v.Bar.FlagE, 3)
+	}
+	expectedStrings := []string{"a,b", `c\d`}
+	if !reflect.DeepEqual(v.Strings, expectedStrings) {
+		t.Errorf("-strings: got %q, expected %q", v.Strings, expectedStrings)
+	}
+
+	visited := []string{}
+	c.FlagSet.Visit(func(f *flag.Flag) {
+		visited = append(visited, f.Name)
+	})
+	if len(visited) != 3 {
+		t.Errorf("FlagSet.Visit: expected the flags set from the file to be visited, got %q", visited)
+	}
+}
+
+func TestConfigParseYAMLFileSeparators(t *testing.T) {
+	path := writeTestFile(t, "config.yaml", `
+db:
+  host: example
+  conn:
+    max-idle: 2
+`)
+
+	for _, sep := range []string{"-", ".", "_"} {
+		var v struct {
+			DB struct {
+				Host string
+				Conn struct {
+					MaxIdle int
+				}
+			}
+		}
+		flags, err := StructOptions{FlagSeparator: sep}.StructToFlags(&v)
+		if err != nil {
+			t.Fatalf("StructToFlags(...): unexpected error: %s", err)
+		}
+		c := &Config{
+			FlagSet: flag.NewFlagSet("flagset", flag.ContinueOnError),
+			Flags:   flags,
+			Files:   []*File{YAMLFile(path)},
+		}
+		c.FlagSet.SetOutput(&bytes.Buffer{})
+
+		os.Clearenv()
+		err = c.Parse([]string{})
+		if err != nil {
+			t.Fatalf("Config.Parse(...) (separator %q): unexpected error: %s", sep, err)
+		}
+		if v.DB.Host != "example" || v.DB.Conn.MaxIdle != 2 {
+			t.Errorf("Config.Parse(...) (separator %q): got %q and %d, expected %q and %d", sep, v.DB.Host, v.DB.Conn.MaxIdle, "example", 2)
+		}
+	}
+}